@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// besuClient is a wrapper around a Hyperledger Besu instance on a separate
+// thread.
+type besuClient struct {
+	*process
+}
+
+// newBesuClient instantiates a new besuClient.
+//
+// The client's data directory is set to a temporary location and the provided
+// blocks are imported on top of the genesis.
+func newBesuClient(ctx context.Context, path string, chain *chainData, verbose bool) (*besuClient, error) {
+	p, err := newProcess(path, chain, ctx.Value(ARGS).(*Args).jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+	b := &besuClient{p}
+
+	// Run besu blocks import. Besu initializes the database from the genesis
+	// file on first use, so there is no separate init step.
+	options := append(b.baseOptions(ctx), "blocks", "import", "--skip-pow-validation-enabled", fmt.Sprintf("--from=%s/chain.rlp", p.workdir))
+	if err := runCmd(ctx, path, verbose, options...); err != nil {
+		return nil, err
+	}
+
+	// Snapshot the datadir, so the client can be reset.
+	if err := p.takeSnapshot(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Start starts besu, but does not wait for the command to exit.
+func (b *besuClient) Start(ctx context.Context, verbose bool) error {
+	fmt.Println("starting client")
+	options := append(b.baseOptions(ctx),
+		fmt.Sprintf("--p2p-port=%s", NETWORKPORT),
+		"--discovery-enabled=false",
+		"--rpc-http-enabled",
+		"--rpc-http-api=ADMIN,ETH,DEBUG,WEB3",
+		fmt.Sprintf("--rpc-http-host=%s", HOST),
+		fmt.Sprintf("--rpc-http-port=%s", PORT),
+		"--rpc-ws-enabled",
+		"--rpc-ws-api=ADMIN,ETH,DEBUG,WEB3",
+		fmt.Sprintf("--rpc-ws-host=%s", HOST),
+		fmt.Sprintf("--rpc-ws-port=%s", WSPORT),
+		"--engine-rpc-enabled",
+		fmt.Sprintf("--engine-rpc-port=%s", AUTHPORT),
+		fmt.Sprintf("--engine-jwt-secret=%s", b.jwtSecretFile()),
+	)
+	return b.start(ctx, verbose, options...)
+}
+
+// baseOptions returns the options shared by every besu invocation.
+func (b *besuClient) baseOptions(ctx context.Context) []string {
+	args := ctx.Value(ARGS).(*Args)
+	return []string{
+		fmt.Sprintf("--data-path=%s", b.workdir),
+		fmt.Sprintf("--genesis-file=%s/genesis.json", b.workdir),
+		fmt.Sprintf("--logging=%s", logLevelName(args.logLevelInt)),
+		"--data-storage-format=FOREST",
+	}
+}
@@ -1,29 +1,43 @@
 package main
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 )
 
-// genSimpleChain generates a short chain with a few basic transactions.
+// genSimpleChain generates a short chain with a few transactions of every
+// type.
+//
+// Blocks 1-3 contain a legacy transfer each, block 4 an access list and a
+// dynamic fee transaction and block 5 a contract creation and a reverted
+// call. Block 6 deploys a contract which emits its calldata as a log with the
+// first two words as topics, and blocks 7 and 8 call it to emit logs.
 func genSimpleChain(engine consensus.Engine) (*core.Genesis, []*types.Block, *types.Block) {
 	var (
-		keyHex  = "9c647b8b7c4e7c3490668fb6c11473619db80c93704c70893d3813af4090c39c"
-		key, _  = crypto.HexToECDSA(keyHex)
-		address = crypto.PubkeyToAddress(key.PublicKey) // 658bdf435d810c91414ec09147daa6db62406379
-		aa      = common.Address{0xaa}
-		bb      = common.Address{0xbb}
-		funds   = big.NewInt(0).Mul(big.NewInt(1337), big.NewInt(params.Ether))
-		gspec   = &core.Genesis{
-			Config:     params.AllEthashProtocolChanges,
-			Alloc:      core.GenesisAlloc{address: {Balance: funds}},
+		keyHex   = "9c647b8b7c4e7c3490668fb6c11473619db80c93704c70893d3813af4090c39c"
+		key, _   = crypto.HexToECDSA(keyHex)
+		address  = crypto.PubkeyToAddress(key.PublicKey) // 658bdf435d810c91414ec09147daa6db62406379
+		key2, _  = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		address2 = crypto.PubkeyToAddress(key2.PublicKey)
+		aa       = common.Address{0xaa}
+		bb       = common.Address{0xbb}
+		cc       = common.Address{0xcc}
+		funds    = big.NewInt(0).Mul(big.NewInt(1337), big.NewInt(params.Ether))
+		emitter  common.Address
+		config   = *params.AllEthashProtocolChanges
+		gspec    = &core.Genesis{
+			Config:     &config,
+			Alloc:      core.GenesisAlloc{address: {Balance: funds}, address2: {Balance: funds}},
 			BaseFee:    big.NewInt(params.InitialBaseFee),
 			Difficulty: common.Big1,
 			GasLimit:   5_000_000,
@@ -53,10 +67,95 @@ func genSimpleChain(engine consensus.Engine) (*core.Genesis, []*types.Block, *ty
 		Storage: storage,
 		Code:    common.Hex2Bytes("600154600354"),
 	}
+	// 0xcc always reverts.
+	gspec.Alloc[cc] = core.GenesisAccount{
+		Balance: common.Big0,
+		Nonce:   1,
+		Code:    common.Hex2Bytes("60006000fd"),
+	}
 
 	genesis := gspec.MustCommit(gendb)
 
-	chain, _ := core.GenerateChain(gspec.Config, genesis, engine, gendb, 4, func(i int, gen *core.BlockGen) {
+	// emit calls the log emitting contract with the given topics.
+	emit := func(gen *core.BlockGen, topic0, topic1 common.Hash) {
+		gen.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   gspec.Config.ChainID,
+			Nonce:     gen.TxNonce(address),
+			To:        &emitter,
+			Gas:       50000,
+			GasTipCap: common.Big2,
+			GasFeeCap: new(big.Int).Add(gen.BaseFee(), common.Big2),
+			Data:      append(topic0.Bytes(), topic1.Bytes()...),
+		}))
+	}
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, engine, gendb, 9, func(i int, gen *core.BlockGen) {
+		switch i {
+		case 3:
+			// Access list transaction reading two storage slots of 0xbb.
+			gen.AddTx(types.MustSignNewTx(key, signer, &types.AccessListTx{
+				ChainID:  gspec.Config.ChainID,
+				Nonce:    gen.TxNonce(address),
+				To:       &bb,
+				Gas:      50000,
+				GasPrice: new(big.Int).Add(gen.BaseFee(), common.Big1),
+				AccessList: types.AccessList{{
+					Address:     bb,
+					StorageKeys: []common.Hash{{0x01}, common.HexToHash("0x03")},
+				}},
+			}))
+			// Dynamic fee transfer from the second sender.
+			gen.AddTx(types.MustSignNewTx(key2, signer, &types.DynamicFeeTx{
+				ChainID:   gspec.Config.ChainID,
+				Nonce:     gen.TxNonce(address2),
+				To:        &address,
+				Value:     big.NewInt(1000),
+				Gas:       params.TxGas,
+				GasTipCap: common.Big2,
+				GasFeeCap: new(big.Int).Add(gen.BaseFee(), common.Big2),
+			}))
+			return
+		case 4:
+			// Contract creation deploying code that returns 42.
+			gen.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+				ChainID:   gspec.Config.ChainID,
+				Nonce:     gen.TxNonce(address),
+				Gas:       100000,
+				GasTipCap: common.Big2,
+				GasFeeCap: new(big.Int).Add(gen.BaseFee(), common.Big2),
+				Data:      common.Hex2Bytes("600a600c600039600a6000f3602a60005260206000f3"),
+			}))
+			// Call to 0xcc, which reverts.
+			gen.AddTx(types.MustSignNewTx(key2, signer, &types.LegacyTx{
+				Nonce:    gen.TxNonce(address2),
+				To:       &cc,
+				Gas:      30000,
+				GasPrice: new(big.Int).Add(gen.BaseFee(), common.Big1),
+			}))
+			return
+		case 5:
+			// Deploy the log emitter:
+			//   calldatacopy(0, 0, calldatasize)
+			//   log2(0, calldatasize, calldata[0:32], calldata[32:64])
+			nonce := gen.TxNonce(address2)
+			gen.AddTx(types.MustSignNewTx(key2, signer, &types.DynamicFeeTx{
+				ChainID:   gspec.Config.ChainID,
+				Nonce:     nonce,
+				Gas:       100000,
+				GasTipCap: common.Big2,
+				GasFeeCap: new(big.Int).Add(gen.BaseFee(), common.Big2),
+				Data:      common.Hex2Bytes("6011600c60003960116000f3366000600037602035600035366000a200"),
+			}))
+			emitter = crypto.CreateAddress(address2, nonce)
+			return
+		case 6:
+			emit(gen, common.HexToHash("0xaa"), common.HexToHash("0x01"))
+			emit(gen, common.HexToHash("0xbb"), common.HexToHash("0x02"))
+			return
+		case 7:
+			emit(gen, common.HexToHash("0xaa"), common.HexToHash("0x02"))
+			return
+		}
 		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(address), address, big.NewInt(1000), params.TxGas, new(big.Int).Add(gen.BaseFee(), common.Big1), nil), signer, key)
 		gen.AddTx(tx)
 		if i == 1 {
@@ -75,20 +174,105 @@ func genSimpleChain(engine consensus.Engine) (*core.Genesis, []*types.Block, *ty
 		}
 	})
 
-	// Modify block so that recorded gas used does not equal actual.
-	bad := chain[len(chain)-1]
-	h := bad.Header()
+	// Modify the last block so that its recorded gas used does not equal the
+	// actual gas used. It is left out of the chain as an invalid child of the
+	// head.
+	h := chain[len(chain)-1].Header()
 	h.GasUsed += 1
-	bad.WithSeal(h)
-	sealedBlock := make(chan *types.Block, 1)
-	if err := engine.Seal(nil, bad, sealedBlock, nil); err != nil {
-		panic(err)
-	}
+	bad := chain[len(chain)-1].WithSeal(h)
 
 	chain = chain[:len(chain)-1]
 	return gspec, chain, bad
 }
 
+// genPreMergeChain generates a short proof-of-work chain with uncles. The
+// genesis has the given difficulty.
+//
+// Blocks 1, 2 and 5 contain a legacy transfer each. Block 3 includes a sibling
+// of block 2 as uncle and block 4 two siblings of block 3.
+func genPreMergeChain(engine consensus.Engine, difficulty *big.Int) (*core.Genesis, []*types.Block) {
+	var (
+		key, _  = crypto.HexToECDSA("9c647b8b7c4e7c3490668fb6c11473619db80c93704c70893d3813af4090c39c")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = big.NewInt(0).Mul(big.NewInt(1337), big.NewInt(params.Ether))
+		config  = *params.AllEthashProtocolChanges
+		gspec   = &core.Genesis{
+			Config:     &config,
+			Alloc:      core.GenesisAlloc{address: {Balance: funds}},
+			BaseFee:    big.NewInt(params.InitialBaseFee),
+			Difficulty: difficulty,
+			GasLimit:   5_000_000,
+		}
+		gendb   = rawdb.NewMemoryDatabase()
+		signer  = types.LatestSigner(gspec.Config)
+		genesis = gspec.MustCommit(gendb)
+	)
+
+	// uncle creates an empty sibling of the block after parent. The block
+	// generator fills in the time, difficulty and gas fields.
+	uncle := func(parent *types.Block, coinbase common.Address) *types.Header {
+		return &types.Header{
+			ParentHash:  parent.Hash(),
+			UncleHash:   types.EmptyUncleHash,
+			Coinbase:    coinbase,
+			Root:        parent.Root(),
+			TxHash:      types.EmptyRootHash,
+			ReceiptHash: types.EmptyRootHash,
+			Number:      new(big.Int).Add(parent.Number(), common.Big1),
+		}
+	}
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, engine, gendb, 5, func(i int, gen *core.BlockGen) {
+		switch i {
+		case 2:
+			gen.AddUncle(uncle(gen.PrevBlock(i-2), common.Address{0xc1}))
+			return
+		case 3:
+			gen.AddUncle(uncle(gen.PrevBlock(i-2), common.Address{0xc2}))
+			gen.AddUncle(uncle(gen.PrevBlock(i-2), common.Address{0xc3}))
+			return
+		}
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(address), address, big.NewInt(1000), params.TxGas, new(big.Int).Add(gen.BaseFee(), common.Big1), nil), signer, key)
+		gen.AddTx(tx)
+	})
+	return gspec, chain
+}
+
+// sealingEngine is an ethash engine which seals blocks as soon as they are
+// assembled, so that generated blocks build on sealed parents. Uncles are
+// sealed before they are included.
+type sealingEngine struct {
+	*ethash.Ethash
+	verbose bool // print the number of each sealed block
+}
+
+// FinalizeAndAssemble implements consensus.Engine, returning the sealed block.
+func (e *sealingEngine) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt, withdrawals []*types.Withdrawal) (*types.Block, error) {
+	for _, uncle := range uncles {
+		sealed, err := e.seal(types.NewBlockWithHeader(uncle))
+		if err != nil {
+			return nil, err
+		}
+		uncle.Nonce, uncle.MixDigest = types.EncodeNonce(sealed.Nonce()), sealed.MixDigest()
+	}
+	block, err := e.Ethash.FinalizeAndAssemble(chain, header, state, txs, uncles, receipts, withdrawals)
+	if err != nil {
+		return nil, err
+	}
+	if e.verbose {
+		fmt.Printf("sealing block %d\n", block.NumberU64())
+	}
+	return e.seal(block)
+}
+
+func (e *sealingEngine) seal(block *types.Block) (*types.Block, error) {
+	results := make(chan *types.Block, 1)
+	if err := e.Ethash.Seal(nil, block, results, nil); err != nil {
+		return nil, err
+	}
+	return <-results, nil
+}
+
 func uintptr(x uint64) *uint64 {
 	return &x
 }
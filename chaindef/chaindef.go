@@ -0,0 +1,312 @@
+// Package chaindef compiles declarative test chain definitions into a genesis
+// and a list of blocks.
+//
+// A definition consists of a regular genesis, named accounts which sign
+// transactions, and the transactions and withdrawals of each block:
+//
+//	{
+//	  "name": "transfers",
+//	  "genesis": {"gasLimit": "0x1c9c380", "difficulty": "0x0", "alloc": {}},
+//	  "accounts": {
+//	    "alice": {"key": "0x9c64...", "balance": "0x3635c9adc5dea00000"}
+//	  },
+//	  "blocks": [
+//	    {"txs": [{"from": "alice", "to": "0xaa00000000000000000000000000000000000000", "value": "0x1"}]},
+//	    {"txs": [{"from": "alice", "deploy": "counter", "data": "0x6001600055"}]},
+//	    {"txs": [{"type": "dynamicFee", "from": "alice", "to": "counter"}]}
+//	  ]
+//	}
+//
+// Definitions may also be written in YAML, in which case hex values must be
+// quoted.
+package chaindef
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"gopkg.in/yaml.v3"
+)
+
+// Transaction types.
+const (
+	TxLegacy     = "legacy"
+	TxAccessList = "accessList"
+	TxDynamicFee = "dynamicFee"
+)
+
+// defaultGas is the gas limit of transactions that don't specify one.
+const defaultGas = 100_000
+
+// Definition is a declarative description of a test chain.
+type Definition struct {
+	Name     string              `json:"name"`
+	Genesis  *core.Genesis       `json:"genesis"`
+	Accounts map[string]*Account `json:"accounts"`
+	Blocks   []*Block            `json:"blocks"`
+}
+
+// Account is a named account that signs transactions. Its balance is added to
+// the genesis allocation.
+type Account struct {
+	Key     string                `json:"key"`
+	Balance *math.HexOrDecimal256 `json:"balance"`
+}
+
+// Block lists the contents of a block.
+type Block struct {
+	Txs         []*Tx               `json:"txs"`
+	Withdrawals []*types.Withdrawal `json:"withdrawals"`
+}
+
+// Tx describes a transaction. Fees are derived from the base fee of the block.
+type Tx struct {
+	Type       string                `json:"type"`       // legacy (default), accessList or dynamicFee
+	From       string                `json:"from"`       // name of the sending account
+	To         string                `json:"to"`         // address, account or contract name, empty for creations
+	Deploy     string                `json:"deploy"`     // name of the contract created by the transaction
+	Value      *math.HexOrDecimal256 `json:"value"`      // transferred wei
+	Gas        math.HexOrDecimal64   `json:"gas"`        // gas limit, defaults to 100000
+	Data       hexutil.Bytes         `json:"data"`       // calldata or init code
+	AccessList types.AccessList      `json:"accessList"` // access list of non-legacy transactions
+}
+
+// Load reads a chain definition from a JSON or, if the file has a .yaml or
+// .yml extension, YAML file. The name of the chain defaults to the file name.
+func Load(filename string) (*Definition, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(filename)
+	if ext == ".yaml" || ext == ".yml" {
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if def.Name == "" {
+		def.Name = strings.TrimSuffix(filepath.Base(filename), ext)
+	}
+	if def.Genesis == nil {
+		return nil, fmt.Errorf("%s: missing genesis", filename)
+	}
+	return &def, nil
+}
+
+// yamlToJSON converts a YAML document into JSON, so that it can be decoded
+// using the JSON encoding of the go-ethereum types.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalizeYAML(v))
+}
+
+// normalizeYAML converts maps with non-string keys, which JSON can't encode.
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range v {
+			v[k] = normalizeYAML(elem)
+		}
+		return v
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			out[fmt.Sprint(k)] = normalizeYAML(elem)
+		}
+		return out
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeYAML(elem)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// Generate builds the chain described by the definition with the consensus
+// engine. If the genesis doesn't have a config, all forks up to Shanghai are
+// enabled and the chain is post-merge from genesis.
+func (d *Definition) Generate(engine consensus.Engine) (*core.Genesis, []*types.Block, error) {
+	gspec := *d.Genesis
+	if gspec.Config == nil {
+		gspec.Config = mergedConfig()
+	}
+	gspec.Alloc = make(core.GenesisAlloc, len(d.Genesis.Alloc)+len(d.Accounts))
+	for addr, account := range d.Genesis.Alloc {
+		gspec.Alloc[addr] = account
+	}
+
+	// Derive the named accounts and fund them.
+	var (
+		keys  = make(map[string]*ecdsa.PrivateKey, len(d.Accounts))
+		names = make(map[string]common.Address, len(d.Accounts))
+	)
+	for name, account := range d.Accounts {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(account.Key, "0x"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("account %s: invalid key: %w", name, err)
+		}
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		keys[name], names[name] = key, addr
+		if account.Balance != nil {
+			alloc := gspec.Alloc[addr]
+			alloc.Balance = new(big.Int).Set((*big.Int)(account.Balance))
+			gspec.Alloc[addr] = alloc
+		}
+	}
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = gspec.MustCommit(db)
+		signer  = types.LatestSigner(gspec.Config)
+		genErr  error
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, engine, db, len(d.Blocks), func(i int, gen *core.BlockGen) {
+		if genErr != nil {
+			return
+		}
+		// The block generator panics on invalid transactions.
+		defer func() {
+			if r := recover(); r != nil {
+				genErr = fmt.Errorf("block %d: %v", i+1, r)
+			}
+		}()
+		for j, txdef := range d.Blocks[i].Txs {
+			tx, err := txdef.sign(gspec.Config.ChainID, gen, signer, keys, names)
+			if err != nil {
+				genErr = fmt.Errorf("block %d, tx %d: %w", i+1, j, err)
+				return
+			}
+			gen.AddTx(tx)
+		}
+		for _, w := range d.Blocks[i].Withdrawals {
+			gen.AddWithdrawal(w)
+		}
+	})
+	if genErr != nil {
+		return nil, nil, genErr
+	}
+	return &gspec, blocks, nil
+}
+
+// sign creates and signs the transaction. If the transaction deploys a named
+// contract, the contract's address is added to names.
+func (tx *Tx) sign(chainID *big.Int, gen *core.BlockGen, signer types.Signer, keys map[string]*ecdsa.PrivateKey, names map[string]common.Address) (*types.Transaction, error) {
+	key, ok := keys[tx.From]
+	if !ok {
+		return nil, fmt.Errorf("unknown sender: %s", tx.From)
+	}
+	var (
+		from  = names[tx.From]
+		nonce = gen.TxNonce(from)
+		gas   = uint64(tx.Gas)
+		value = new(big.Int)
+		to    *common.Address
+	)
+	if gas == 0 {
+		gas = defaultGas
+	}
+	if tx.Value != nil {
+		value.Set((*big.Int)(tx.Value))
+	}
+	if tx.To != "" {
+		addr, ok := names[tx.To]
+		if !ok {
+			if !common.IsHexAddress(tx.To) {
+				return nil, fmt.Errorf("unknown recipient: %s", tx.To)
+			}
+			addr = common.HexToAddress(tx.To)
+		}
+		to = &addr
+	}
+	if tx.Deploy != "" {
+		if to != nil {
+			return nil, fmt.Errorf("contract %s deployed by a call", tx.Deploy)
+		}
+		if _, ok := names[tx.Deploy]; ok {
+			return nil, fmt.Errorf("name %s already in use", tx.Deploy)
+		}
+		names[tx.Deploy] = crypto.CreateAddress(from, nonce)
+	}
+
+	// Before London, pay the initial base fee.
+	baseFee := gen.BaseFee()
+	if baseFee == nil {
+		baseFee = big.NewInt(params.InitialBaseFee)
+	}
+
+	var txdata types.TxData
+	switch tx.Type {
+	case "", TxLegacy:
+		if len(tx.AccessList) != 0 {
+			return nil, fmt.Errorf("legacy transaction with access list")
+		}
+		txdata = &types.LegacyTx{
+			Nonce:    nonce,
+			To:       to,
+			Value:    value,
+			Gas:      gas,
+			GasPrice: new(big.Int).Add(baseFee, common.Big1),
+			Data:     tx.Data,
+		}
+	case TxAccessList:
+		txdata = &types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			To:         to,
+			Value:      value,
+			Gas:        gas,
+			GasPrice:   new(big.Int).Add(baseFee, common.Big1),
+			Data:       tx.Data,
+			AccessList: tx.AccessList,
+		}
+	case TxDynamicFee:
+		txdata = &types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			To:         to,
+			Value:      value,
+			Gas:        gas,
+			GasTipCap:  common.Big2,
+			GasFeeCap:  new(big.Int).Add(baseFee, common.Big2),
+			Data:       tx.Data,
+			AccessList: tx.AccessList,
+		}
+	default:
+		return nil, fmt.Errorf("unknown transaction type: %s", tx.Type)
+	}
+	return types.SignNewTx(key, signer, txdata)
+}
+
+// mergedConfig returns a chain config with all forks up to Shanghai enabled at
+// genesis, which is post-merge.
+func mergedConfig() *params.ChainConfig {
+	config := *params.AllEthashProtocolChanges
+	config.TerminalTotalDifficulty = common.Big0
+	config.TerminalTotalDifficultyPassed = true
+	shanghai := uint64(0)
+	config.ShanghaiTime = &shanghai
+	return &config
+}
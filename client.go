@@ -3,14 +3,18 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/lightclient/rpctestgen/jwt"
 )
 
 // Client is an interface for generically interacting with Ethereum clients.
@@ -22,57 +26,193 @@ type Client interface {
 	// JSON-RPC.
 	HttpAddr() string
 
+	// WsAddr returns the address where the client is serving its JSON-RPC
+	// over websocket, or an empty string if it isn't.
+	WsAddr() string
+
+	// AuthAddr returns the address where the client is serving the engine
+	// API, authenticated with the run's JWT secret, or an empty string if it
+	// isn't.
+	AuthAddr() string
+
+	// IPCPath returns the path of the Unix domain socket where the client is
+	// serving its JSON-RPC, or an empty string if it isn't.
+	IPCPath() string
+
+	// Reset stops the client and restores its data to the state right after
+	// the chain was imported. The client must be started again afterwards.
+	Reset() error
+
 	// Close closes the client.
 	Close() error
 }
 
+// errResetUnsupported is returned by clients that can't be reset.
+var errResetUnsupported = errors.New("client does not support reset")
+
+// process is a client binary running as a child process with its own working
+// directory.
+type process struct {
+	cmd      *exec.Cmd
+	path     string
+	workdir  string
+	snapshot string // copy of the pristine working directory
+}
+
+// newProcess creates a temporary working directory and writes the genesis,
+// the chain and the JWT secret to it.
+func newProcess(path string, chain *chainData, jwtSecret [32]byte) (*process, error) {
+	tmp, err := os.MkdirTemp("", "rpctestgen-*")
+	if err != nil {
+		return nil, err
+	}
+	if err := jwt.WriteSecret(fmt.Sprintf("%s/jwtsecret", tmp), jwtSecret); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	if err := writeGenesis(fmt.Sprintf("%s/genesis.json", tmp), chain.gspec); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	if err := writeChain(fmt.Sprintf("%s/chain.rlp", tmp), chain.blocks); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	return &process{path: path, workdir: tmp}, nil
+}
+
+// start starts the binary with the provided options, but does not wait for
+// the command to exit. The binary runs in the working directory.
+func (p *process) start(ctx context.Context, verbose bool, options ...string) error {
+	p.cmd = exec.CommandContext(ctx, p.path, options...)
+	p.cmd.Dir = p.workdir
+	if verbose {
+		p.cmd.Stdout = os.Stdout
+		p.cmd.Stderr = os.Stderr
+	}
+	return p.cmd.Start()
+}
+
+// HttpAddr returns the address where the client is servering its JSON-RPC.
+func (p *process) HttpAddr() string {
+	return fmt.Sprintf("http://%s:%s", HOST, PORT)
+}
+
+// WsAddr returns the address where the client is serving its JSON-RPC over
+// websocket.
+func (p *process) WsAddr() string {
+	return fmt.Sprintf("ws://%s:%s", HOST, WSPORT)
+}
+
+// AuthAddr returns the address where the client is serving the engine API.
+func (p *process) AuthAddr() string {
+	return fmt.Sprintf("http://%s:%s", HOST, AUTHPORT)
+}
+
+// IPCPath returns an empty string, clients serving JSON-RPC over IPC override
+// it.
+func (p *process) IPCPath() string {
+	return ""
+}
+
+// ipcFile returns the path of the IPC socket in the working directory.
+func (p *process) ipcFile() string {
+	return fmt.Sprintf("%s/rpc.ipc", p.workdir)
+}
+
+// jwtSecretFile returns the path of the file holding the JWT secret.
+func (p *process) jwtSecretFile() string {
+	return fmt.Sprintf("%s/jwtsecret", p.workdir)
+}
+
+// takeSnapshot copies the working directory, so that it can be restored by
+// Reset. It must be called once the chain is imported and before the process
+// is started.
+func (p *process) takeSnapshot() error {
+	tmp, err := os.MkdirTemp("", "rpctestgen-snapshot-*")
+	if err != nil {
+		return err
+	}
+	p.snapshot = tmp
+	return copyDir(p.workdir, p.snapshot)
+}
+
+// Reset kills the process and restores its working directory from the
+// snapshot.
+func (p *process) Reset() error {
+	if p.snapshot == "" {
+		return errResetUnsupported
+	}
+	p.kill()
+	if err := os.RemoveAll(p.workdir); err != nil {
+		return err
+	}
+	return copyDir(p.snapshot, p.workdir)
+}
+
+// Close kills the process and removes its working directory.
+func (p *process) Close() error {
+	p.kill()
+	if p.snapshot != "" {
+		os.RemoveAll(p.snapshot)
+	}
+	return os.RemoveAll(p.workdir)
+}
+
+func (p *process) kill() {
+	if p.cmd != nil && p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+	}
+	p.cmd = nil
+}
+
 // gethClient is a wrapper around a go-ethereum instance on a separate thread.
 type gethClient struct {
-	cmd     *exec.Cmd
-	path    string
-	workdir string
-	blocks  []*types.Block
-	genesis *core.Genesis
+	*process
 }
 
 // newGethClient instantiates a new GethClient.
 //
 // The client's data directory is set to a temporary location and it
 // initializes with the genesis and the provided blocks.
-func newGethClient(ctx context.Context, path string, genesis *core.Genesis, blocks []*types.Block, verbose bool) (*gethClient, error) {
-	tmp, err := os.MkdirTemp("", "rpctestgen-*")
+func newGethClient(ctx context.Context, path string, chain *chainData, verbose bool) (*gethClient, error) {
+	p, err := newProcess(path, chain, ctx.Value(ARGS).(*Args).jwtSecret)
 	if err != nil {
 		return nil, err
 	}
-	if err := writeGenesis(fmt.Sprintf("%s/genesis.json", tmp), genesis); err != nil {
-		return nil, err
-	}
-	if err := writeChain(fmt.Sprintf("%s/chain.rlp", tmp), blocks); err != nil {
-		return nil, err
-	}
 
 	var (
 		args     = ctx.Value(ARGS).(*Args)
-		datadir  = fmt.Sprintf("--datadir=%s", tmp)
+		datadir  = fmt.Sprintf("--datadir=%s", p.workdir)
 		gcmode   = "--gcmode=archive"
 		loglevel = fmt.Sprintf("--verbosity=%d", args.logLevelInt)
 	)
 
 	// Run geth init.
-	options := []string{datadir, gcmode, loglevel, "init", fmt.Sprintf("%s/genesis.json", tmp)}
+	options := []string{datadir, gcmode, loglevel, "init", fmt.Sprintf("%s/genesis.json", p.workdir)}
 	err = runCmd(ctx, path, verbose, options...)
 	if err != nil {
 		return nil, err
 	}
 
-	// Run geth import.
-	options = []string{datadir, gcmode, loglevel, "import", fmt.Sprintf("%s/chain.rlp", tmp)}
+	// Run geth import. Unless the chain is sealed, its proof-of-work can't
+	// be verified.
+	options = []string{datadir, gcmode, loglevel}
+	if !args.Ethash {
+		options = append(options, "--fakepow")
+	}
+	options = append(options, "import", fmt.Sprintf("%s/chain.rlp", p.workdir))
 	err = runCmd(ctx, path, verbose, options...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &gethClient{path: path, genesis: genesis, blocks: blocks, workdir: tmp}, nil
+	// Snapshot the datadir, so the client can be reset.
+	if err := p.takeSnapshot(); err != nil {
+		return nil, err
+	}
+	return &gethClient{p}, nil
 }
 
 // Start starts geth, but does not wait for the command to exit.
@@ -87,36 +227,81 @@ func (g *gethClient) Start(ctx context.Context, verbose bool) error {
 			"--gcmode=archive",
 			"--nodiscover",
 			"--http",
-			"--http.api=admin,eth,debug",
+			"--http.api=admin,eth,debug,web3",
 			fmt.Sprintf("--http.addr=%s", HOST),
 			fmt.Sprintf("--http.port=%s", PORT),
+			"--ws",
+			"--ws.api=admin,eth,debug,web3",
+			fmt.Sprintf("--ws.addr=%s", HOST),
+			fmt.Sprintf("--ws.port=%s", WSPORT),
+			fmt.Sprintf("--authrpc.addr=%s", HOST),
+			fmt.Sprintf("--authrpc.port=%s", AUTHPORT),
+			fmt.Sprintf("--authrpc.jwtsecret=%s", g.jwtSecretFile()),
+			fmt.Sprintf("--ipcpath=%s", g.IPCPath()),
 		}
 	)
-	g.cmd = exec.CommandContext(
-		ctx,
-		g.path,
-		options...,
-	)
-	if verbose {
-		g.cmd.Stdout = os.Stdout
-		g.cmd.Stderr = os.Stderr
-	}
-	if err := g.cmd.Start(); err != nil {
-		return err
+	return g.start(ctx, verbose, options...)
+}
+
+// IPCPath returns the path of geth's IPC socket.
+func (g *gethClient) IPCPath() string {
+	return g.ipcFile()
+}
+
+// externalClient is a client that is managed outside of rpctestgen. It is
+// expected to already be running and to have imported the test chain.
+type externalClient struct {
+	addr     string
+	wsAddr   string
+	authAddr string
+	ipcPath  string
+}
+
+// newExternalClient instantiates a new externalClient serving JSON-RPC at
+// addr and, optionally, over websocket at wsAddr, the engine API at authAddr
+// and over IPC at ipcPath.
+func newExternalClient(addr, wsAddr, authAddr, ipcPath string) (*externalClient, error) {
+	if addr == "" && ipcPath == "" {
+		return nil, fmt.Errorf("external client requires --rpc or --ipc")
 	}
+	return &externalClient{addr: addr, wsAddr: wsAddr, authAddr: authAddr, ipcPath: ipcPath}, nil
+}
+
+// Start is a no-op, the client is already running.
+func (e *externalClient) Start(ctx context.Context, verbose bool) error {
 	return nil
 }
 
 // HttpAddr returns the address where the client is servering its JSON-RPC.
-func (g *gethClient) HttpAddr() string {
-	return fmt.Sprintf("http://%s:%s", HOST, PORT)
+func (e *externalClient) HttpAddr() string {
+	return e.addr
+}
+
+// WsAddr returns the address where the client is serving its JSON-RPC over
+// websocket.
+func (e *externalClient) WsAddr() string {
+	return e.wsAddr
 }
 
-// Close closes the client.
-func (g *gethClient) Close() error {
-	g.cmd.Process.Kill()
-	g.cmd.Wait()
-	return os.RemoveAll(g.workdir)
+// AuthAddr returns the address where the client is serving the engine API.
+func (e *externalClient) AuthAddr() string {
+	return e.authAddr
+}
+
+// IPCPath returns the path of the socket where the client is serving its
+// JSON-RPC over IPC.
+func (e *externalClient) IPCPath() string {
+	return e.ipcPath
+}
+
+// Reset is not supported, the client's data is managed externally.
+func (e *externalClient) Reset() error {
+	return errResetUnsupported
+}
+
+// Close is a no-op, the client's lifetime is managed externally.
+func (e *externalClient) Close() error {
+	return nil
 }
 
 // runCmd runs a command and outputs the command's stdout and stderr to the
@@ -133,6 +318,62 @@ func runCmd(ctx context.Context, path string, verbose bool, args ...string) erro
 	return nil
 }
 
+// logLevelName converts the numeric log level into the upper case level names
+// used by besu and nethermind.
+func logLevelName(lvl int) string {
+	switch lvl {
+	case 1:
+		return "ERROR"
+	case 2:
+		return "WARN"
+	case 3:
+		return "INFO"
+	case 4:
+		return "DEBUG"
+	default:
+		return "TRACE"
+	}
+}
+
+// copyDir recursively copies the contents of the src directory to dst.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case !info.Mode().IsRegular():
+			// Skip sockets, e.g. the IPC endpoint.
+			return nil
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
 // writeGenesis writes the genesis to disk.
 func writeGenesis(filename string, genesis *core.Genesis) error {
 	out, err := json.MarshalIndent(genesis, "", "  ")
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lightclient/rpctestgen/fixture"
+)
+
+// convert converts every matching fixture which isn't in the target format yet
+// and writes it to the same path relative to the output directory.
+func convert(args *Args) error {
+	re, err := regexp.Compile(args.TestsRegex)
+	if err != nil {
+		return err
+	}
+	var converted int
+	err = fixture.Walk(args.TestsRoot, re, func(name, path string) error {
+		if fixture.Format(path) == args.To {
+			return nil
+		}
+		f, err := fixture.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to parse %s: %w", name, err)
+		}
+		out := filepath.Join(args.OutDir, name+"."+args.To)
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+			return err
+		}
+		chain, method, test := splitName(name)
+		if err := f.WriteFile(out, chain, method, test); err != nil {
+			return fmt.Errorf("unable to write %s: %w", out, err)
+		}
+		converted++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d fixtures converted\n", converted)
+	return nil
+}
+
+// splitName splits the name of a fixture, which is stored as
+// chain/method/test.format, into its parts. Parts above the root of the
+// walked directory are empty.
+func splitName(name string) (chain, method, test string) {
+	parts := strings.Split(strings.Trim(filepath.ToSlash(name), "/"), "/")
+	test = parts[len(parts)-1]
+	if len(parts) > 1 {
+		method = parts[len(parts)-2]
+	}
+	if len(parts) > 2 {
+		chain = parts[len(parts)-3]
+	}
+	return chain, method, test
+}
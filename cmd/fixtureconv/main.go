@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alexflint/go-arg"
+	"github.com/lightclient/rpctestgen/fixture"
+)
+
+type Args struct {
+	To         string `arg:"--to,required" help:"format to convert the fixtures to (io, json)"`
+	TestsRoot  string `arg:"--tests" help:"path to tests directory" default:"tests"`
+	OutDir     string `arg:"--out" help:"directory where the converted fixtures will be written (defaults to the tests directory)"`
+	TestsRegex string `arg:"--regexp" help:"regular expression to match tests to convert" default:".*"`
+}
+
+func main() {
+	var args Args
+	arg.MustParse(&args)
+	if err := fixture.CheckFormat(args.To); err != nil {
+		exit(err)
+	}
+	if args.OutDir == "" {
+		args.OutDir = args.TestsRoot
+	}
+	if err := convert(&args); err != nil {
+		exit(err)
+	}
+}
+
+func exit(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
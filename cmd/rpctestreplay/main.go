@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alexflint/go-arg"
+	"github.com/lightclient/rpctestgen/jwt"
+)
+
+type Args struct {
+	Endpoint     string `arg:"--rpc" help:"JSON-RPC endpoint of the client under test" default:"http://127.0.0.1:8545"`
+	AuthEndpoint string `arg:"--authrpc" help:"engine API endpoint of the client under test, engine API fixtures are skipped without it"`
+	JWTSecret    string `arg:"--jwtsecret" help:"path to the hex encoded JWT secret of the engine API"`
+	TestsRoot    string `arg:"--tests" help:"path to tests directory" default:"tests"`
+	TestsRegex   string `arg:"--regexp" help:"regular expression to match tests to replay" default:".*"`
+	Mutating     bool   `arg:"--mutating" help:"replay fixtures of tests that change the client's state, the client must be reset in between"`
+
+	jwtSecret [32]byte
+}
+
+func main() {
+	var args Args
+	arg.MustParse(&args)
+	if args.AuthEndpoint != "" {
+		if args.JWTSecret == "" {
+			exit(fmt.Errorf("--authrpc requires --jwtsecret"))
+		}
+		secret, err := jwt.ReadSecret(args.JWTSecret)
+		if err != nil {
+			exit(err)
+		}
+		args.jwtSecret = secret
+	}
+	if err := replay(&args); err != nil {
+		exit(err)
+	}
+}
+
+func exit(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
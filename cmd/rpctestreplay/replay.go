@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/lightclient/rpctestgen/fixture"
+	"github.com/lightclient/rpctestgen/jwt"
+)
+
+// replay sends the requests of every matching fixture to the endpoint and
+// compares the client's responses with the recorded ones.
+func replay(args *Args) error {
+	re, err := regexp.Compile(args.TestsRegex)
+	if err != nil {
+		return err
+	}
+	var (
+		client  = &http.Client{Timeout: 5 * time.Second}
+		passed  int
+		failed  int
+		skipped int
+	)
+	err = fixture.Walk(args.TestsRoot, re, func(name, path string) error {
+		f, err := fixture.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to parse %s: %w", name, err)
+		}
+		if reason := skipReason(f, args); reason != "" {
+			skipped++
+			fmt.Printf("skip %s: %s\n", name, reason)
+			return nil
+		}
+		diffs, err := replayTest(context.Background(), client, args, f)
+		if err != nil {
+			return fmt.Errorf("unable to replay %s: %w", name, err)
+		}
+		if len(diffs) == 0 {
+			passed++
+			fmt.Printf("pass %s\n", name)
+			return nil
+		}
+		failed++
+		fmt.Printf("FAIL %s\n", name)
+		for _, d := range diffs {
+			fmt.Printf("    %s\n", d)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	if failed != 0 {
+		return fmt.Errorf("%d tests failed", failed)
+	}
+	return nil
+}
+
+// issuedIDs maps the methods which return an id issued by the client, which
+// later requests refer to, to the path of the id in their response.
+var issuedIDs = map[string]string{
+	"eth_newFilter":                   "result",
+	"eth_newBlockFilter":              "result",
+	"eth_newPendingTransactionFilter": "result",
+	"engine_forkchoiceUpdatedV1":      "result.payloadId",
+	"engine_forkchoiceUpdatedV2":      "result.payloadId",
+}
+
+// replayTest replays each exchange of a fixture in order and returns the
+// differences between the recorded responses and the ones received. Values
+// normalized by the fixture's rules are only compared loosely. Calls of the
+// engine API are sent to the authenticated endpoint.
+//
+// Ids issued by the client, e.g. filter ids, differ between the filling and
+// the replaying client. Later requests carrying a recorded id are sent with the
+// id the replaying client returned instead.
+func replayTest(ctx context.Context, client *http.Client, args *Args, f *fixture.Fixture) ([]string, error) {
+	var (
+		out   []string
+		rules = f.Header().Normalize
+		ids   = make(map[string]string) // recorded id => replayed id
+	)
+	for i, ex := range f.Exchanges {
+		var (
+			endpoint = args.Endpoint
+			auth     rpc.HTTPAuth
+		)
+		if callsEngine(ex) {
+			endpoint, auth = args.AuthEndpoint, jwt.Auth(args.jwtSecret)
+		}
+		req := ex.Request
+		for recorded, replayed := range ids {
+			req = bytes.ReplaceAll(req, []byte(strconv.Quote(recorded)), []byte(strconv.Quote(replayed)))
+		}
+		got, err := send(ctx, client, endpoint, auth, req)
+		if err != nil {
+			return nil, err
+		}
+		if path, ok := issuedIDs[methodOf(ex)]; ok {
+			recorded, replayed := stringAt(ex.Response, path), stringAt(got, path)
+			if recorded != "" && replayed != "" {
+				ids[recorded] = replayed
+			}
+		}
+		diffs, err := fixture.DiffNormalized(&fixture.Exchange{Request: req, Response: got}, ex, rules)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range diffs {
+			out = append(out, fmt.Sprintf("exchange %d: %s", i, d))
+		}
+	}
+	return out, nil
+}
+
+// skipReason returns why the fixture can't be replayed over HTTP, or an empty
+// string if it can. Subscriptions are only served over websocket, and even
+// there the requests of a fixture refer to the subscription ids the filling
+// client returned, so they can't be replayed verbatim. Calls of the engine API
+// require its endpoint. Fixtures of tests that change the client's state are
+// only replayed on request, since replaying one after another without a reset
+// of the client fails, e.g. their transactions use the same nonce.
+func skipReason(f *fixture.Fixture, args *Args) string {
+	if f.Header().Mutates && !args.Mutating {
+		return "changes the client's state, requires --mutating"
+	}
+	for _, ex := range f.Exchanges {
+		if ex.Request == nil {
+			return "notifications can't be replayed"
+		}
+		for _, m := range ex.Methods() {
+			if m == "eth_subscribe" {
+				return "subscriptions can't be replayed"
+			}
+		}
+		if callsEngine(ex) && args.AuthEndpoint == "" {
+			return "engine API calls require --authrpc"
+		}
+	}
+	return ""
+}
+
+// methodOf returns the method called by the exchange, or an empty string for
+// batches.
+func methodOf(ex *fixture.Exchange) string {
+	if ex.IsBatch() {
+		return ""
+	}
+	return ex.Methods()[0]
+}
+
+// stringAt returns the string at the dot-separated path of members in the
+// message, or an empty string if there is none.
+func stringAt(msg json.RawMessage, path string) string {
+	var v interface{}
+	if err := json.Unmarshal(msg, &v); err != nil {
+		return ""
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		v = m[key]
+	}
+	s, _ := v.(string)
+	return s
+}
+
+// callsEngine reports whether the exchange calls a method of the engine API.
+func callsEngine(ex *fixture.Exchange) bool {
+	for _, m := range ex.Methods() {
+		if strings.HasPrefix(m, "engine_") {
+			return true
+		}
+	}
+	return false
+}
+
+// send posts a raw JSON-RPC request to the endpoint, authenticated by auth if
+// it isn't nil, and returns the raw response body.
+func send(ctx context.Context, client *http.Client, endpoint string, auth rpc.HTTPAuth, body []byte) (json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth != nil {
+		if err := auth(req.Header); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf), nil
+}
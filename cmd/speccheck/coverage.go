@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	openrpc "github.com/open-rpc/meta-schema"
+)
+
+// methodCoverage records how well the tests exercise a single method.
+type methodCoverage struct {
+	tests    int          // number of round trips
+	errors   int          // number of round trips with an error response
+	params   map[int]bool // indices of parameters set in some round trip
+	branches map[int]bool // indices of result alternatives observed
+}
+
+// checkCoverage cross-references the methods of the spec with the tests and
+// reports the parts of the spec the tests don't exercise.
+func checkCoverage(args *Args) error {
+	re, err := regexp.Compile(args.TestsRegex)
+	if err != nil {
+		return err
+	}
+	methods, err := parseMethodSchemas(args.SpecPath)
+	if err != nil {
+		return err
+	}
+	rts, err := parseRoundTrips(args.TestsRoot, re)
+	if err != nil {
+		return err
+	}
+
+	// Gather coverage of each method.
+	coverage := make(map[string]*methodCoverage)
+	for name := range methods {
+		coverage[name] = &methodCoverage{params: make(map[int]bool), branches: make(map[int]bool)}
+	}
+	for _, rt := range rts {
+		cov, ok := coverage[rt.method]
+		if !ok {
+			// Undefined methods are reported by the regular check.
+			continue
+		}
+		cov.tests++
+		if rt.error != nil {
+			cov.errors++
+			continue
+		}
+		for i, p := range rt.params {
+			if string(p) != "null" {
+				cov.params[i] = true
+			}
+		}
+		branches, err := resultBranches(methods[rt.method].result)
+		if err != nil {
+			return err
+		}
+		for i, branch := range branches {
+			if validate(rt.response, branch, fmt.Sprintf("%s.result.oneOf[%d]", rt.method, i)) == nil {
+				cov.branches[i] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Report the gaps.
+	var untested, happyOnly, params, branches []string
+	for _, name := range names {
+		cov := coverage[name]
+		if cov.tests == 0 {
+			untested = append(untested, name)
+			continue
+		}
+		if cov.errors == 0 {
+			happyOnly = append(happyOnly, name)
+		}
+		for i, param := range methods[name].params {
+			if (param.Required != nil && bool(*param.Required)) || cov.params[i] {
+				continue
+			}
+			params = append(params, fmt.Sprintf("%s.param[%d] (%s)", name, i, paramName(param.Name)))
+		}
+		alternatives, err := resultBranches(methods[name].result)
+		if err != nil {
+			return err
+		}
+		for i, branch := range alternatives {
+			if !cov.branches[i] {
+				branches = append(branches, fmt.Sprintf("%s.result.oneOf[%d] (%s)", name, i, branchTitle(branch)))
+			}
+		}
+	}
+	printSection("methods without tests", untested)
+	printSection("methods without error tests", happyOnly)
+	printSection("optional parameters never set", params)
+	printSection("result alternatives never observed", branches)
+	fmt.Printf("%d of %d methods tested.\n", len(names)-len(untested), len(names))
+	return nil
+}
+
+// resultBranches returns the alternatives of a result schema that is a oneOf,
+// or nothing otherwise.
+func resultBranches(result []byte) ([][]byte, error) {
+	var schema struct {
+		OneOf       []json.RawMessage `json:"oneOf"`
+		Definitions json.RawMessage   `json:"definitions"`
+	}
+	if err := json.Unmarshal(result, &schema); err != nil {
+		return nil, err
+	}
+	var out [][]byte
+	for _, branch := range schema.OneOf {
+		// Carry over the definitions recursive references point to.
+		if schema.Definitions != nil {
+			var b map[string]json.RawMessage
+			if err := json.Unmarshal(branch, &b); err != nil {
+				// Boolean schema.
+				out = append(out, branch)
+				continue
+			}
+			b["definitions"] = schema.Definitions
+			buf, err := json.Marshal(b)
+			if err != nil {
+				return nil, err
+			}
+			branch = buf
+		}
+		out = append(out, branch)
+	}
+	return out, nil
+}
+
+// branchTitle returns the title of the schema, if it has one.
+func branchTitle(schema []byte) string {
+	var s struct {
+		Title string `json:"title"`
+	}
+	if json.Unmarshal(schema, &s) != nil || s.Title == "" {
+		return "untitled"
+	}
+	return s.Title
+}
+
+func paramName(name *openrpc.ContentDescriptorObjectName) string {
+	if name == nil {
+		return "unnamed"
+	}
+	return string(*name)
+}
+
+func printSection(title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Printf("%s:\n", title)
+	for _, line := range lines {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println()
+}
@@ -5,18 +5,34 @@ import (
 	"os"
 
 	"github.com/alexflint/go-arg"
+	"github.com/lightclient/rpctestgen/report"
 )
 
 type Args struct {
 	SpecPath   string `arg:"--spec" help:"path to client binary" default:"openrpc.json"`
 	TestsRoot  string `arg:"--tests" help:"path to tests directory" default:"tests"`
 	TestsRegex string `arg:"--regexp" help:"regular expression to match tests to check" deafult:".*"`
+	Coverage   bool   `arg:"--coverage" help:"report the parts of the spec the tests don't exercise"`
+	Report     string `arg:"--report" help:"write a report of the checked round trips (junit, json)"`
+	ReportFile string `arg:"--report-out" help:"path of the report (defaults to report.xml or report.json)"`
 	Verbose    bool   `arg:"-v,--verbose" help:"verbosity level of rpctestgen"`
 }
 
 func main() {
 	var args Args
 	arg.MustParse(&args)
+	if args.Report != "" {
+		if err := report.CheckFormat(args.Report); err != nil {
+			exit(err)
+		}
+		if args.ReportFile == "" {
+			args.ReportFile = report.DefaultFile(args.Report)
+		}
+	}
+	if args.Coverage {
+		exit(checkCoverage(&args))
+		return
+	}
 	if err := checkSpec(&args); err != nil {
 		exit(err)
 	}
@@ -4,10 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"regexp"
-	"strings"
 
+	"github.com/lightclient/rpctestgen/fixture"
 	openrpc "github.com/open-rpc/meta-schema"
 )
 
@@ -16,7 +15,7 @@ type jsonrpcMessage struct {
 	ID      json.RawMessage `json:"id,omitempty"`
 	Method  string          `json:"method,omitempty"`
 	Params  json.RawMessage `json:"params,omitempty"`
-	Error   *jsonError      `json:"error,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
 }
 
@@ -27,27 +26,13 @@ type jsonError struct {
 }
 
 // parseRoundTrips walks a root directory and parses round trip HTTP exchanges
-// from files that match the regular expression.
+// from files that match the regular expression. Fixtures may be in either the
+// line based or the JSON format.
 func parseRoundTrips(root string, re *regexp.Regexp) ([]*roundTrip, error) {
 	rts := make([]*roundTrip, 0)
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			fmt.Printf("unable to walk path: %s\n", err)
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
-		if fname := info.Name(); !strings.HasSuffix(fname, ".io") {
-			return nil
-		}
-		pathname := strings.TrimSuffix(strings.TrimPrefix(path, root), ".io")
-		if !re.MatchString(pathname) {
-			fmt.Println("skip", pathname)
-			return nil // skip
-		}
+	err := fixture.Walk(root, re, func(name, path string) error {
 		// Found a good test, parse it and append to list.
-		test, err := parseTest(pathname, path)
+		test, err := parseTest(name, path)
 		if err != nil {
 			return err
 		}
@@ -62,48 +47,68 @@ func parseRoundTrips(root string, re *regexp.Regexp) ([]*roundTrip, error) {
 
 // parseTest parses a single test into a slice of HTTP round trips.
 func parseTest(testname string, filename string) ([]*roundTrip, error) {
-	data, err := os.ReadFile(filename)
+	f, err := fixture.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	rts := make([]*roundTrip, 0)
-	var req *jsonrpcMessage
-	for _, line := range strings.Split(string(data), "\n") {
-		line = strings.TrimSpace(line)
-		switch {
-		case len(line) == 0 || strings.HasPrefix(line, "//"):
-			// Skip comments, blank lines.
-			continue
-		case strings.HasPrefix(line, ">> "):
-			req = &jsonrpcMessage{}
-			if err := json.Unmarshal([]byte(line[3:]), &req); err != nil {
-				return nil, err
-			}
-		case strings.HasPrefix(line, "<< "):
-			if req == nil {
-				return nil, fmt.Errorf("response w/o corresponding request")
+	var (
+		rts    = make([]*roundTrip, 0)
+		header = f.Header()
+	)
+	for _, ex := range f.Exchanges {
+		// Check each call of a batch on its own.
+		calls, err := ex.Calls()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", testname, err)
+		}
+		for _, call := range calls {
+			if call.Request == nil {
+				// Skip subscription notifications and responses to
+				// invalid calls.
+				continue
 			}
-			var resp jsonrpcMessage
-			if err := json.Unmarshal([]byte(line[3:]), &resp); err != nil {
+			rt, err := parseRoundTrip(testname, header.About, call)
+			if err != nil {
 				return nil, err
 			}
-			// Parse parameters into slice of string.
-			params, err := parseParamValues(req.Params)
-			if err != nil {
-				return nil, fmt.Errorf("unable to parse params: %s %v", err, req.Params)
+			// Check the values the fixture only constrains loosely,
+			// e.g. to be any quantity.
+			if rt.violations, err = fixture.CheckRules(call, header.Normalize); err != nil {
+				return nil, fmt.Errorf("%s: %w", testname, err)
 			}
-			rts = append(rts, &roundTrip{req.Method, testname, params, resp.Result})
-			req = nil
-		default:
-			return nil, fmt.Errorf("invalid line in test: %s", line)
+			rts = append(rts, rt)
 		}
 	}
-	if req != nil {
-		return nil, fmt.Errorf("unhandled request")
-	}
 	return rts, nil
 }
 
+// parseRoundTrip parses a single call and its response.
+func parseRoundTrip(testname, about string, ex *fixture.Exchange) (*roundTrip, error) {
+	var req, resp jsonrpcMessage
+	if err := json.Unmarshal(ex.Request, &req); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(ex.Response, &resp); err != nil {
+		return nil, err
+	}
+	// Parse parameters into slice of string.
+	params, err := parseParamValues(req.Params)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse params: %s %v", err, req.Params)
+	}
+	if resp.Error != nil && resp.Result != nil {
+		return nil, fmt.Errorf("response contains both result and error: %s", ex.Response)
+	}
+	return &roundTrip{
+		method:   req.Method,
+		name:     testname,
+		about:    about,
+		params:   params,
+		response: resp.Result,
+		error:    resp.Error,
+	}, nil
+}
+
 // parseParamValues parses each parameter out of the raw json value in its own byte
 // slice.
 func parseParamValues(raw json.RawMessage) ([][]byte, error) {
@@ -134,6 +139,19 @@ func parseMethodSchemas(filename string) (map[string]*methodSchema, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Resolve references before decoding the document, as the decoder
+	// can't distinguish them from the objects they point to.
+	var raw interface{}
+	if err := json.Unmarshal(spec, &raw); err != nil {
+		return nil, err
+	}
+	resolved, err := resolveRefs(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve references: %w", err)
+	}
+	if spec, err = json.Marshal(resolved); err != nil {
+		return nil, err
+	}
 	var doc openrpc.OpenrpcDocument
 	if err := json.Unmarshal(spec, &doc); err != nil {
 		return nil, err
@@ -146,12 +164,18 @@ func parseMethodSchemas(filename string) (map[string]*methodSchema, error) {
 
 		// Read parameter schemas.
 		for _, param := range *method.MethodObject.Params {
-			if param.ReferenceObject != nil {
-				return nil, fmt.Errorf("parameter references not supported")
-			}
 			schema.params = append(schema.params, *param.ContentDescriptorObject)
 		}
 
+		// Read the error codes the method may return.
+		if method.MethodObject.Errors != nil {
+			for _, e := range *method.MethodObject.Errors {
+				if e.ErrorObject.Code != nil {
+					schema.errors = append(schema.errors, int(*e.ErrorObject.Code))
+				}
+			}
+		}
+
 		// Read result schema.
 		buf, err := json.Marshal(method.MethodObject.Result.ContentDescriptorObject.Schema)
 		if err != nil {
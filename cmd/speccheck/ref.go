@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// resolver inlines the local references ("$ref": "#/...") of an OpenRPC
+// document, e.g. to its components, so that every method is self-contained.
+//
+// Recursive schemas can't be inlined. References that form a cycle are instead
+// rewritten to point into "definitions", which is attached to every parameter
+// and result schema.
+type resolver struct {
+	root   interface{}
+	cache  map[string]interface{} // resolved values by reference
+	active map[string]bool        // references currently being resolved
+	cyclic map[string]interface{} // recursive definitions by name
+}
+
+// resolveRefs returns a copy of the decoded OpenRPC document with all local
+// references resolved.
+func resolveRefs(doc interface{}) (interface{}, error) {
+	r := &resolver{
+		root:   doc,
+		cache:  make(map[string]interface{}),
+		active: make(map[string]bool),
+		cyclic: make(map[string]interface{}),
+	}
+	out, err := r.resolve(doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.cyclic) != 0 {
+		if err := r.attachDefinitions(out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (r *resolver) resolve(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			return r.resolveRef(ref)
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			resolved, err := r.resolve(elem)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = resolved
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			resolved, err := r.resolve(elem)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func (r *resolver) resolveRef(ref string) (interface{}, error) {
+	if out, ok := r.cache[ref]; ok {
+		return out, nil
+	}
+	name := definitionName(ref)
+	if r.active[ref] {
+		// Cycle, refer to the definition instead.
+		if _, ok := r.cyclic[name]; !ok {
+			r.cyclic[name] = nil
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + name}, nil
+	}
+	target, err := lookup(r.root, ref)
+	if err != nil {
+		return nil, err
+	}
+	r.active[ref] = true
+	out, err := r.resolve(target)
+	delete(r.active, ref)
+	if err != nil {
+		return nil, err
+	}
+	if def, ok := r.cyclic[name]; ok && def == nil {
+		r.cyclic[name] = out
+	}
+	r.cache[ref] = out
+	return out, nil
+}
+
+// attachDefinitions adds the recursive definitions to the parameter and result
+// schemas of every method in the resolved document.
+func (r *resolver) attachDefinitions(doc interface{}) error {
+	d, ok := doc.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid document")
+	}
+	methods, _ := d["methods"].([]interface{})
+	for _, m := range methods {
+		method, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		descriptors, _ := method["params"].([]interface{})
+		descriptors = append(descriptors, method["result"])
+		for _, cd := range descriptors {
+			cd, ok := cd.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			schema, ok := cd["schema"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// Resolved values may be shared, so copy before modifying.
+			withDefs := make(map[string]interface{}, len(schema)+1)
+			for k, v := range schema {
+				withDefs[k] = v
+			}
+			withDefs["definitions"] = r.cyclic
+			cd["schema"] = withDefs
+		}
+	}
+	return nil
+}
+
+// lookup returns the value the local reference points to in doc.
+func lookup(doc interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference: %s", ref)
+	}
+	cur := doc
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolvable reference: %s", ref)
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("unresolvable reference: %s", ref)
+			}
+			cur = v[i]
+		default:
+			return nil, fmt.Errorf("unresolvable reference: %s", ref)
+		}
+	}
+	return cur, nil
+}
+
+// definitionName returns the name under which the target of ref is stored in
+// "definitions", e.g. "components.schemas.Block".
+func definitionName(ref string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(ref, "#/"), "/", ".")
+}
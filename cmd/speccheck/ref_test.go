@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestResolveRefs(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		result string // resolved result schema of the first method
+		err    string
+	}{
+		{
+			name: "nested",
+			doc: `{
+	"methods": [{"name": "eth_getBlockByNumber", "result": {"name": "block", "schema": {"$ref": "#/components/schemas/Block"}}}],
+	"components": {"schemas": {
+		"Block": {"type": "object", "properties": {"header": {"$ref": "#/components/schemas/Header"}}},
+		"Header": {"type": "object", "properties": {"hash": {"$ref": "#/components/schemas/hash32"}}},
+		"hash32": {"type": "string", "pattern": "^0x[0-9a-f]{64}$"}
+	}}
+}`,
+			result: `{"type": "object", "properties": {"header": {"type": "object", "properties": {"hash": {"type": "string", "pattern": "^0x[0-9a-f]{64}$"}}}}}`,
+		},
+		{
+			name: "recursive",
+			doc: `{
+	"methods": [{"name": "debug_traceCall", "result": {"name": "trace", "schema": {"$ref": "#/components/schemas/Node"}}}],
+	"components": {"schemas": {
+		"Node": {"type": "object", "properties": {"calls": {"type": "array", "items": {"$ref": "#/components/schemas/Node"}}}}
+	}}
+}`,
+			result: `{
+	"type": "object",
+	"properties": {"calls": {"type": "array", "items": {"$ref": "#/definitions/components.schemas.Node"}}},
+	"definitions": {"components.schemas.Node": {
+		"type": "object",
+		"properties": {"calls": {"type": "array", "items": {"$ref": "#/definitions/components.schemas.Node"}}}
+	}}
+}`,
+		},
+		{
+			name: "unresolvable",
+			doc:  `{"methods": [{"name": "eth_chainId", "result": {"name": "id", "schema": {"$ref": "#/components/schemas/uint"}}}]}`,
+			err:  "unresolvable reference: #/components/schemas/uint",
+		},
+		{
+			name: "unsupported",
+			doc:  `{"methods": [{"name": "eth_chainId", "result": {"name": "id", "schema": {"$ref": "schemas.json#/uint"}}}]}`,
+			err:  "unsupported reference: schemas.json#/uint",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			out, err := resolveRefs(doc)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("wrong error: got %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			method := out.(map[string]interface{})["methods"].([]interface{})[0].(map[string]interface{})
+			got := method["result"].(map[string]interface{})["schema"]
+			var want interface{}
+			if err := json.Unmarshal([]byte(tt.result), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				g, _ := json.Marshal(got)
+				t.Errorf("wrong result schema:\ngot  %s\nwant %s", g, tt.result)
+			}
+		})
+	}
+}
+
+func TestResolveRefsRecursiveValidation(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(`{
+	"methods": [{"name": "debug_traceCall", "result": {"name": "trace", "schema": {"$ref": "#/components/schemas/Node"}}}],
+	"components": {"schemas": {
+		"Node": {"type": "object", "required": ["type"], "properties": {"type": {"type": "string"}, "calls": {"type": "array", "items": {"$ref": "#/components/schemas/Node"}}}}
+	}}
+}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	out, err := resolveRefs(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	method := out.(map[string]interface{})["methods"].([]interface{})[0].(map[string]interface{})
+	schema, err := json.Marshal(method["result"].(map[string]interface{})["schema"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := `{"type": "CALL", "calls": [{"type": "CALL", "calls": [{"type": "STATICCALL"}]}]}`
+	if err := validate([]byte(valid), schema, "trace"); err != nil {
+		t.Errorf("valid trace rejected: %v", err)
+	}
+	invalid := `{"type": "CALL", "calls": [{"type": "CALL", "calls": [{"calls": []}]}]}`
+	if err := validate([]byte(invalid), schema, "trace"); err == nil {
+		t.Errorf("invalid nested trace accepted")
+	}
+}
@@ -3,9 +3,14 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"regexp"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/lightclient/rpctestgen/report"
 	openrpc "github.com/open-rpc/meta-schema"
 	"github.com/santhosh-tekuri/jsonschema/v5"
 )
@@ -16,15 +21,32 @@ type methodSchema struct {
 	// Schemas
 	params []openrpc.ContentDescriptorObject
 	result []byte
+	errors []int // error codes declared by the spec, if any
 }
 
+// errorSchema is the schema of the JSON-RPC 2.0 error object.
+const errorSchema = `{
+	"type": "object",
+	"required": ["code", "message"],
+	"properties": {
+		"code": {"type": "integer"},
+		"message": {"type": "string"}
+	}
+}`
+
 // roundTrip is a single round trip interaction between a certain JSON-RPC
 // method.
 type roundTrip struct {
 	method   string
 	name     string
+	about    string
 	params   [][]byte
 	response []byte
+	error    []byte
+
+	// violations describes the values of the response which break the
+	// normalization rules of the fixture.
+	violations []string
 }
 
 // checkSpec reads the schemas from the spec and test files, then validates
@@ -47,53 +69,164 @@ func checkSpec(args *Args) error {
 		return err
 	}
 
+	// Check every round trip and collect the violations.
+	var (
+		failures []*failure
+		rep      = report.New("speccheck")
+	)
 	for _, rt := range rts {
-		methodSchema, ok := methods[rt.method]
-		if !ok {
-			return fmt.Errorf("undefined method: %s", rt.method)
+		start := time.Now()
+		err := checkRoundTrip(rt, methods, args.Verbose)
+		rep.Add(rt.method, rt.name, rt.about, time.Since(start), err)
+		if err != nil {
+			failures = append(failures, &failure{rt, err})
 		}
-		// skip validator of test if name includes "invalid" as the schema
-		// doesn't yet support it.
-		// TODO(matt): create error schemas.
-		if strings.Contains(rt.name, "invalid") {
-			continue
+	}
+	if args.Report != "" {
+		if err := rep.WriteFile(args.ReportFile, args.Report); err != nil {
+			return err
 		}
-		if len(methodSchema.params) < len(rt.params) {
-			return fmt.Errorf("too many parameters")
+	}
+	if len(failures) == 0 {
+		fmt.Println("all passing.")
+		return nil
+	}
+	printFailures(failures)
+	fmt.Println()
+	printSummary(rts, failures)
+	return fmt.Errorf("%d of %d round trips failed", len(failures), len(rts))
+}
+
+// failure is a round trip that violates the spec.
+type failure struct {
+	rt  *roundTrip
+	err error
+}
+
+// checkRoundTrip validates a single round trip against the schemas of its
+// method.
+func checkRoundTrip(rt *roundTrip, methods map[string]*methodSchema, verbose bool) error {
+	methodSchema, ok := methods[rt.method]
+	if !ok {
+		return fmt.Errorf("undefined method")
+	}
+	if len(rt.violations) != 0 {
+		return fmt.Errorf("response violates normalization rules: %s", strings.Join(rt.violations, ", "))
+	}
+	// Error responses usually stem from deliberately invalid
+	// parameters, so only the error itself is validated.
+	if rt.error != nil {
+		if err := checkError(rt, methodSchema); err != nil {
+			return fmt.Errorf("invalid error: %w", err)
 		}
-		// Validate each parameter value against their respective schema.
-		for i, schema := range methodSchema.params {
-			if len(rt.params) <= i {
-				if schema.Required == nil || !(*schema.Required) {
-					// skip missing optional values
-					continue
-				}
-				return fmt.Errorf("missing required parameter %s.param[%d]", rt.method, i)
-			}
-			raw, err := json.Marshal(schema.Schema.JSONSchemaObject)
-			if err != nil {
-				return err
-			}
-			if err := validate(rt.params[i], raw, fmt.Sprintf("%s.param[%d]", rt.method, i)); err != nil {
-				return fmt.Errorf("unable to validate parameter: %s", err)
+		return nil
+	}
+	if len(methodSchema.params) < len(rt.params) {
+		return fmt.Errorf("too many parameters (got: %d, want: %d)", len(rt.params), len(methodSchema.params))
+	}
+	// Validate each parameter value against their respective schema.
+	for i, schema := range methodSchema.params {
+		if len(rt.params) <= i {
+			if schema.Required == nil || !(*schema.Required) {
+				// skip missing optional values
+				continue
 			}
+			return fmt.Errorf("missing required parameter %s.param[%d]", rt.method, i)
 		}
-		if err := validate(rt.response, methodSchema.result, fmt.Sprintf("%s.result", rt.method)); err != nil {
-			// Print out the value and schema if there is an error to further debug.
+		raw, err := json.Marshal(schema.Schema.JSONSchemaObject)
+		if err != nil {
+			return err
+		}
+		if err := validate(rt.params[i], raw, fmt.Sprintf("%s.param[%d]", rt.method, i)); err != nil {
+			return fmt.Errorf("unable to validate parameter: %s", err)
+		}
+	}
+	if err := validate(rt.response, methodSchema.result, fmt.Sprintf("%s.result", rt.method)); err != nil {
+		if verbose {
+			// Print out the value and schema to further debug.
 			var schema interface{}
 			json.Unmarshal(methodSchema.result, &schema)
 			buf, _ := json.MarshalIndent(schema, "", "  ")
 			fmt.Println(string(buf))
-			fmt.Println(string(methodSchema.result))
 			fmt.Println(string(rt.response))
-			return fmt.Errorf("invalid result %s: %w", rt.name, err)
 		}
+		return fmt.Errorf("invalid result: %w", err)
 	}
-
-	fmt.Println("all passing.")
 	return nil
 }
 
+// printFailures prints the failures grouped by method and test file.
+func printFailures(failures []*failure) {
+	sort.SliceStable(failures, func(i, j int) bool {
+		a, b := failures[i].rt, failures[j].rt
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.name < b.name
+	})
+	var method, name string
+	for _, f := range failures {
+		if f.rt.method != method {
+			method, name = f.rt.method, ""
+			fmt.Printf("%s\n", method)
+		}
+		if f.rt.name != name {
+			name = f.rt.name
+			fmt.Printf("  %s\n", name)
+		}
+		fmt.Printf("    %s\n", f.err)
+	}
+}
+
+// printSummary prints a table with the number of checked and failed round
+// trips of each method.
+func printSummary(rts []*roundTrip, failures []*failure) {
+	var (
+		total  = make(map[string]int)
+		failed = make(map[string]int)
+	)
+	for _, rt := range rts {
+		total[rt.method]++
+	}
+	for _, f := range failures {
+		failed[f.rt.method]++
+	}
+	methods := make([]string, 0, len(total))
+	for m := range total {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tCHECKED\tFAILED")
+	for _, m := range methods {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", m, total[m], failed[m])
+	}
+	fmt.Fprintf(w, "total\t%d\t%d\n", len(rts), len(failures))
+	w.Flush()
+}
+
+// checkError validates the structure of the error object returned in the round
+// trip and, if the spec declares the errors of the method, its code.
+func checkError(rt *roundTrip, schema *methodSchema) error {
+	if err := validate(rt.error, []byte(errorSchema), fmt.Sprintf("%s.error", rt.method)); err != nil {
+		return err
+	}
+	if len(schema.errors) == 0 {
+		return nil
+	}
+	var e jsonError
+	if err := json.Unmarshal(rt.error, &e); err != nil {
+		return err
+	}
+	for _, code := range schema.errors {
+		if e.Code == code {
+			return nil
+		}
+	}
+	return fmt.Errorf("error code %d not declared by spec (want one of: %v)", e.Code, schema.errors)
+}
+
 // validateParam validates the provided value against schema using the url base.
 func validate(val []byte, baseSchema []byte, url string) error {
 	// Unmarshal value into interface{} so that validator can properly reflect
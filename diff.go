@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lightclient/rpctestgen/fixture"
+	"github.com/lightclient/rpctestgen/report"
+)
+
+// diffClient is one of the clients compared in diff mode.
+type diffClient struct {
+	label  string // name of the client's output directory
+	typ    string
+	bin    string
+	chains []string
+	jobs   map[string][]*fillJob
+}
+
+// parseDiffClients parses the clients passed to --diff. Each one is a client
+// type, optionally followed by the path of its binary, e.g. geth=/opt/geth.
+func parseDiffClients(specs []string) ([]*diffClient, error) {
+	if len(specs) < 2 {
+		return nil, fmt.Errorf("--diff requires at least two clients")
+	}
+	var (
+		clients = make([]*diffClient, 0, len(specs))
+		seen    = make(map[string]int)
+	)
+	for _, spec := range specs {
+		typ, bin, _ := strings.Cut(spec, "=")
+		if typ == "external" {
+			return nil, fmt.Errorf("--diff doesn't support external clients")
+		}
+		seen[typ]++
+		label := typ
+		if n := seen[typ]; n > 1 {
+			label = fmt.Sprintf("%s-%d", typ, n)
+		}
+		clients = append(clients, &diffClient{label: label, typ: typ, bin: bin})
+	}
+	return clients, nil
+}
+
+// runDiff fills the tests against every client passed to --diff, each into its
+// own subdirectory of the output directory, and reports the tests where the
+// responses of a client differ from the ones of the first client. All clients
+// serve the chains generated for the first one. The returned report holds a
+// failure for each test that differs, it is returned along with an error if
+// any does.
+func runDiff(ctx context.Context, args *Args) (*report.Report, error) {
+	clients, err := parseDiffClients(args.Diff)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range clients {
+		cargs := *args
+		cargs.ClientType, cargs.ClientBin = c.typ, c.bin
+		cargs.OutDir = fmt.Sprintf("%s/%s", args.OutDir, c.label)
+		if i > 0 && args.ChainDir == "" {
+			cargs.ChainDir = fmt.Sprintf("%s/%s", args.OutDir, clients[0].label)
+		}
+		fmt.Printf("filling tests against %s...\n", c.label)
+		c.chains, c.jobs, err = fillTests(context.WithValue(ctx, ARGS, &cargs), &cargs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.label, err)
+		}
+	}
+
+	// Compare the fixtures of each test.
+	var (
+		ref    = clients[0]
+		rep    = report.New("rpctestgen-diff")
+		agreed int
+		differ int
+	)
+	for _, chain := range ref.chains {
+		for i, job := range ref.jobs[chain] {
+			start := time.Now()
+			var diffs []string
+			for _, c := range clients[1:] {
+				d, err := diffJobs(c.jobs[chain][i], job)
+				if err != nil {
+					return nil, err
+				}
+				for _, line := range d {
+					diffs = append(diffs, fmt.Sprintf("%s: %s", c.label, line))
+				}
+			}
+			var (
+				name = fmt.Sprintf("%s/%s/%s", chain, job.method, job.test.Name)
+				err  error
+			)
+			if len(diffs) == 0 {
+				agreed++
+				fmt.Printf("same %s\n", name)
+			} else {
+				differ++
+				fmt.Printf("DIFF %s\n", name)
+				for _, d := range diffs {
+					fmt.Printf("    %s\n", d)
+				}
+				err = fmt.Errorf("responses differ from %s:\n%s", ref.label, strings.Join(diffs, "\n"))
+			}
+			rep.Add(job.method, fmt.Sprintf("%s/%s", chain, job.test.Name), job.test.About, time.Since(start), err)
+		}
+	}
+	fmt.Printf("%d tests agree, %d differ\n", agreed, differ)
+	if differ != 0 {
+		return rep, fmt.Errorf("%d tests differ", differ)
+	}
+	return rep, nil
+}
+
+// diffJobs compares the fixture filled for a test against a client with the
+// one filled against the reference client. Tests that failed to fill are
+// reported as a difference, unless they failed for both clients.
+func diffJobs(got, want *fillJob) ([]string, error) {
+	switch {
+	case got.err != nil && want.err != nil:
+		return nil, nil
+	case got.err != nil:
+		return []string{fmt.Sprintf("fill failed: %s", got.err)}, nil
+	case want.err != nil:
+		return []string{fmt.Sprintf("fill succeeded, reference failed: %s", want.err)}, nil
+	}
+	g, err := fixture.ReadFile(got.filename)
+	if err != nil {
+		return nil, err
+	}
+	w, err := fixture.ReadFile(want.filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Exchanges) != len(w.Exchanges) {
+		return []string{fmt.Sprintf("exchange count mismatch (got: %d, want: %d)", len(g.Exchanges), len(w.Exchanges))}, nil
+	}
+	var (
+		out   []string
+		rules = w.Header().Normalize
+	)
+	for i := range w.Exchanges {
+		d, err := diffExchange(g.Exchanges[i], w.Exchanges[i], rules)
+		if err != nil {
+			// Responses that can't be compared are a difference too.
+			d = []string{err.Error()}
+		}
+		for _, line := range d {
+			out = append(out, fmt.Sprintf("exchange %d: %s", i, line))
+		}
+	}
+	return out, nil
+}
+
+// diffExchange compares the responses of two exchanges, normalized by the
+// rules. The responses of a batch are matched by id, so clients may answer the
+// calls in any order.
+func diffExchange(got, want *fixture.Exchange, rules []fixture.Rule) ([]string, error) {
+	if !got.IsBatch() || !want.IsBatch() {
+		return fixture.DiffNormalized(got, want, rules)
+	}
+	g, err := got.Calls()
+	if err != nil {
+		return nil, err
+	}
+	w, err := want.Calls()
+	if err != nil {
+		return nil, err
+	}
+	if len(g) != len(w) {
+		return []string{fmt.Sprintf("batch length mismatch (got: %d, want: %d)", len(g), len(w))}, nil
+	}
+	var out []string
+	for i := range w {
+		d, err := fixture.DiffNormalized(g[i], w[i], rules)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range d {
+			out = append(out, fmt.Sprintf("[%d]: %s", i, line))
+		}
+	}
+	return out, nil
+}
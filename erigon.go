@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// erigonClient is a wrapper around an erigon instance on a separate thread.
+type erigonClient struct {
+	*process
+}
+
+// newErigonClient instantiates a new erigonClient.
+//
+// The client's data directory is set to a temporary location and it
+// initializes with the genesis and the provided blocks.
+func newErigonClient(ctx context.Context, path string, chain *chainData, verbose bool) (*erigonClient, error) {
+	p, err := newProcess(path, chain, ctx.Value(ARGS).(*Args).jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		args     = ctx.Value(ARGS).(*Args)
+		datadir  = fmt.Sprintf("--datadir=%s", p.workdir)
+		loglevel = fmt.Sprintf("--verbosity=%d", args.logLevelInt)
+	)
+
+	// Run erigon init.
+	options := []string{"init", datadir, loglevel, fmt.Sprintf("%s/genesis.json", p.workdir)}
+	if err := runCmd(ctx, path, verbose, options...); err != nil {
+		return nil, err
+	}
+
+	// Run erigon import.
+	options = []string{"import", datadir, loglevel, fmt.Sprintf("%s/chain.rlp", p.workdir)}
+	if err := runCmd(ctx, path, verbose, options...); err != nil {
+		return nil, err
+	}
+
+	// Snapshot the datadir, so the client can be reset.
+	if err := p.takeSnapshot(); err != nil {
+		return nil, err
+	}
+	return &erigonClient{p}, nil
+}
+
+// Start starts erigon, but does not wait for the command to exit.
+func (e *erigonClient) Start(ctx context.Context, verbose bool) error {
+	fmt.Println("starting client")
+	var (
+		args    = ctx.Value(ARGS).(*Args)
+		options = []string{
+			fmt.Sprintf("--datadir=%s", e.workdir),
+			fmt.Sprintf("--verbosity=%d", args.logLevelInt),
+			fmt.Sprintf("--port=%s", NETWORKPORT),
+			"--prune=disabled",
+			"--nodiscover",
+			"--private.api.addr=",
+			"--http",
+			"--http.api=admin,eth,debug,web3",
+			fmt.Sprintf("--http.addr=%s", HOST),
+			fmt.Sprintf("--http.port=%s", PORT),
+			"--ws",
+			fmt.Sprintf("--authrpc.addr=%s", HOST),
+			fmt.Sprintf("--authrpc.port=%s", AUTHPORT),
+			fmt.Sprintf("--authrpc.jwtsecret=%s", e.jwtSecretFile()),
+		}
+	)
+	return e.start(ctx, verbose, options...)
+}
+
+// WsAddr returns the address where the client is serving its JSON-RPC over
+// websocket. Erigon serves websocket on the same port as HTTP.
+func (e *erigonClient) WsAddr() string {
+	return fmt.Sprintf("ws://%s:%s", HOST, PORT)
+}
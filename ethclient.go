@@ -3,40 +3,173 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/ethclient/gethclient"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	"github.com/lightclient/rpctestgen/jwt"
 )
 
 type ethclientHandler struct {
 	ethclient  *ethclient.Client
 	gethclient *gethclient.Client
 	rpc        *rpc.Client
+	ws         *rpc.Client
+	engine     *rpc.Client
 	logFile    *os.File
-	transport  *loggingRoundTrip
+	log        *testLog
+	conns      []*loggingConn
+	addr       string       // address of the regular transport
+	httpClient *http.Client // logging HTTP client of the regular transport
 }
 
-func newEthclientHandler(addr string) (*ethclientHandler, error) {
-	rt := &loggingRoundTrip{
-		inner: http.DefaultTransport,
-	}
-	httpClient := rpc.WithHTTPClient(&http.Client{Transport: rt})
+// newEthclientHandler connects to the client's endpoints. The addresses of the
+// regular and the websocket transport may also be the path of an IPC socket,
+// in which case each of them gets its own connection to the socket.
+func newEthclientHandler(addr string, wsAddr string, authAddr string, jwtSecret [32]byte) (*ethclientHandler, error) {
+	var (
+		log = &testLog{}
+		rt  = &loggingRoundTrip{
+			w:     log,
+			inner: http.DefaultTransport,
+		}
+	)
+	handler := &ethclientHandler{log: log, addr: addr, httpClient: &http.Client{Transport: rt}}
+	httpClient := rpc.WithHTTPClient(handler.httpClient)
 	ctx := context.Background()
-	rpcClient, err := rpc.DialOptions(ctx, addr, httpClient)
+
+	var err error
+	if isIPCPath(addr) {
+		handler.rpc, err = handler.dialIPC(ctx, addr)
+	} else {
+		handler.rpc, err = rpc.DialOptions(ctx, addr, httpClient)
+	}
+	if err != nil {
+		handler.Close()
+		return nil, err
+	}
+	handler.ethclient = ethclient.NewClient(handler.rpc)
+	handler.gethclient = gethclient.New(handler.rpc)
+
+	// Connect the websocket transport, if the client serves one.
+	if wsAddr != "" {
+		if isIPCPath(wsAddr) {
+			handler.ws, err = handler.dialIPC(ctx, wsAddr)
+		} else {
+			handler.ws, err = handler.dialWebsocket(ctx, wsAddr)
+		}
+		if err != nil {
+			handler.Close()
+			return nil, err
+		}
+	}
+
+	// Connect the engine API, if the client serves it. Requests are
+	// authenticated with a JWT derived from the secret.
+	if authAddr != "" {
+		auth := rpc.WithHTTPAuth(jwt.Auth(jwtSecret))
+		handler.engine, err = rpc.DialOptions(ctx, authAddr, httpClient, auth)
+		if err != nil {
+			handler.Close()
+			return nil, err
+		}
+	}
+	return handler, nil
+}
+
+// isIPCPath reports whether the address is the path of an IPC socket rather
+// than a URL.
+func isIPCPath(addr string) bool {
+	return addr != "" && !strings.Contains(addr, "://")
+}
+
+func (l *ethclientHandler) dialWebsocket(ctx context.Context, addr string) (*rpc.Client, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	return l.dialMessages(ctx, &wsMessages{conn: conn})
+}
+
+func (l *ethclientHandler) dialIPC(ctx context.Context, path string) (*rpc.Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return l.dialMessages(ctx, newIPCMessages(conn))
+}
+
+// dialMessages creates a client on top of the message connection, logging
+// its messages like loggingRoundTrip does for HTTP.
+func (l *ethclientHandler) dialMessages(ctx context.Context, conn messageConn) (*rpc.Client, error) {
+	lc := newLoggingConn(conn, l.log)
+	c, err := rpc.DialIO(ctx, lc, lc)
+	if err != nil {
+		lc.Close()
+		return nil, err
+	}
+	l.conns = append(l.conns, lc)
+	return c, nil
+}
+
+// Send sends a raw message over the regular transport, bypassing the rpc
+// client, and returns the raw response. The messages are logged like the ones
+// of the rpc client.
+func (l *ethclientHandler) Send(ctx context.Context, msg json.RawMessage) (json.RawMessage, error) {
+	if isIPCPath(l.addr) {
+		return l.sendIPC(ctx, msg)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.addr, bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return compact(body), nil
+}
+
+// sendIPC sends a raw message over a connection of its own to the IPC socket
+// and reads a single response.
+func (l *ethclientHandler) sendIPC(ctx context.Context, msg json.RawMessage) (json.RawMessage, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", l.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	ipc := newIPCMessages(conn)
+	fmt.Fprintf(l.log, ">> %s\n", compact(msg))
+	if err := ipc.WriteMessage(compact(msg)); err != nil {
+		return nil, err
+	}
+	resp, err := ipc.ReadMessage()
 	if err != nil {
 		return nil, err
 	}
-	return &ethclientHandler{
-		ethclient.NewClient(rpcClient),
-		gethclient.New(rpcClient),
-		rpcClient,
-		nil,
-		rt}, nil
+	resp = compact(resp)
+	fmt.Fprintf(l.log, "<< %s\n", resp)
+	return resp, nil
 }
 
 func (l *ethclientHandler) RotateLog(filename string) error {
@@ -50,16 +183,62 @@ func (l *ethclientHandler) RotateLog(filename string) error {
 		return err
 	}
 	l.logFile = f
-	l.transport.w = f
+	l.log.setWriter(f)
 	return nil
 }
 
+// LogTo writes the exchanges to w instead of a log file.
+func (l *ethclientHandler) LogTo(w io.Writer) {
+	l.log.setWriter(w)
+}
+
 func (l *ethclientHandler) Close() {
+	// Close the connections first to unblock the clients' read loops.
+	for _, conn := range l.conns {
+		conn.Close()
+	}
+	for _, c := range []*rpc.Client{l.rpc, l.ws, l.engine} {
+		if c != nil {
+			c.Close()
+		}
+	}
 	if l.logFile != nil {
 		l.logFile.Close()
 	}
 }
 
+// testLog serializes writes to the test log from the different transports.
+type testLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *testLog) setWriter(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w = w
+}
+
+func (l *testLog) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil {
+		return len(b), nil
+	}
+	return l.w.Write(b)
+}
+
+// compact removes insignificant whitespace from a JSON message, so that the
+// message, e.g. a batch, takes up a single line of the test log. Invalid JSON
+// is only trimmed.
+func compact(msg []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, msg); err != nil {
+		return bytes.TrimSpace(msg)
+	}
+	return buf.Bytes()
+}
+
 // loggingRoundTrip writes requests and responses to the test log.
 type loggingRoundTrip struct {
 	w     io.Writer
@@ -73,7 +252,7 @@ func (rt *loggingRoundTrip) RoundTrip(req *http.Request) (*http.Response, error)
 	if err != nil {
 		return nil, err
 	}
-	fmt.Fprintf(rt.w, ">> %s\n", bytes.TrimSpace(reqBytes))
+	fmt.Fprintf(rt.w, ">> %s\n", compact(reqBytes))
 	reqCopy := *req
 	reqCopy.Body = io.NopCloser(bytes.NewReader(reqBytes))
 
@@ -91,6 +270,112 @@ func (rt *loggingRoundTrip) RoundTrip(req *http.Request) (*http.Response, error)
 	}
 	respCopy := *resp
 	respCopy.Body = io.NopCloser(bytes.NewReader(respBytes))
-	fmt.Fprintf(rt.w, "<< %s\n", bytes.TrimSpace(respBytes))
+	fmt.Fprintf(rt.w, "<< %s\n", compact(respBytes))
 	return &respCopy, nil
 }
+
+// messageConn is a connection which transfers whole JSON-RPC messages.
+type messageConn interface {
+	ReadMessage() ([]byte, error)
+	WriteMessage(msg []byte) error
+	Close() error
+}
+
+// loggingConn adapts a message connection to the byte stream expected by
+// rpc.DialIO and writes every message to the test log, including notifications
+// pushed by the server.
+type loggingConn struct {
+	conn messageConn
+	w    io.Writer
+	r    *io.PipeReader
+	pw   *io.PipeWriter
+}
+
+func newLoggingConn(conn messageConn, w io.Writer) *loggingConn {
+	r, pw := io.Pipe()
+	c := &loggingConn{conn: conn, w: w, r: r, pw: pw}
+	go c.readLoop()
+	return c
+}
+
+// readLoop logs each incoming message and forwards it to the reading side of
+// the pipe, delimited by a newline.
+func (c *loggingConn) readLoop() {
+	for {
+		msg, err := c.conn.ReadMessage()
+		if err != nil {
+			c.pw.CloseWithError(err)
+			return
+		}
+		msg = compact(msg)
+		fmt.Fprintf(c.w, "<< %s\n", msg)
+		if _, err := c.pw.Write(append(msg, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+func (c *loggingConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// Write sends b as a single message. The rpc codec encodes exactly one
+// message per call.
+func (c *loggingConn) Write(b []byte) (int, error) {
+	msg := bytes.TrimSpace(b)
+	fmt.Fprintf(c.w, ">> %s\n", compact(msg))
+	if err := c.conn.WriteMessage(msg); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *loggingConn) Close() error {
+	c.pw.Close()
+	c.r.Close()
+	return c.conn.Close()
+}
+
+// wsMessages sends each message as a websocket text frame.
+type wsMessages struct {
+	conn *websocket.Conn
+}
+
+func (ws *wsMessages) ReadMessage() ([]byte, error) {
+	_, msg, err := ws.conn.ReadMessage()
+	return msg, err
+}
+
+func (ws *wsMessages) WriteMessage(msg []byte) error {
+	return ws.conn.WriteMessage(websocket.TextMessage, msg)
+}
+
+func (ws *wsMessages) Close() error {
+	return ws.conn.Close()
+}
+
+// ipcMessages splits the stream of a Unix domain socket into messages. Sent
+// messages are delimited by a newline.
+type ipcMessages struct {
+	conn net.Conn
+	dec  *json.Decoder
+}
+
+func newIPCMessages(conn net.Conn) *ipcMessages {
+	return &ipcMessages{conn: conn, dec: json.NewDecoder(conn)}
+}
+
+func (ipc *ipcMessages) ReadMessage() ([]byte, error) {
+	var msg json.RawMessage
+	err := ipc.dec.Decode(&msg)
+	return msg, err
+}
+
+func (ipc *ipcMessages) WriteMessage(msg []byte) error {
+	_, err := ipc.conn.Write(append(msg, '\n'))
+	return err
+}
+
+func (ipc *ipcMessages) Close() error {
+	return ipc.conn.Close()
+}
@@ -0,0 +1,113 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Diff compares two JSON-RPC messages and returns a description of every
+// field where they differ. The "id" member of the top-level message is ignored.
+func Diff(got, want json.RawMessage) ([]string, error) {
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal got: %w", err)
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal want: %w", err)
+	}
+	if gm, ok := g.(map[string]interface{}); ok {
+		delete(gm, "id")
+	}
+	if wm, ok := w.(map[string]interface{}); ok {
+		delete(wm, "id")
+	}
+	return diffValues("", g, w), nil
+}
+
+// diffValues recursively compares two decoded JSON values.
+func diffValues(path string, got, want interface{}) []string {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return []string{mismatch(path, got, want)}
+		}
+		var out []string
+		for _, k := range unionKeys(g, w) {
+			gv, gok := g[k]
+			wv, wok := w[k]
+			switch {
+			case !gok:
+				out = append(out, fmt.Sprintf("%s: missing (want: %s)", join(path, k), encode(wv)))
+			case !wok:
+				out = append(out, fmt.Sprintf("%s: unexpected (got: %s)", join(path, k), encode(gv)))
+			default:
+				out = append(out, diffValues(join(path, k), gv, wv)...)
+			}
+		}
+		return out
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			return []string{mismatch(path, got, want)}
+		}
+		if len(g) != len(w) {
+			return []string{fmt.Sprintf("%s: length mismatch (got: %d, want: %d)", display(path), len(g), len(w))}
+		}
+		var out []string
+		for i := range w {
+			out = append(out, diffValues(fmt.Sprintf("%s[%d]", path, i), g[i], w[i])...)
+		}
+		return out
+	default:
+		if encode(got) != encode(want) {
+			return []string{mismatch(path, got, want)}
+		}
+		return nil
+	}
+}
+
+func mismatch(path string, got, want interface{}) string {
+	return fmt.Sprintf("%s: got %s, want %s", display(path), encode(got), encode(want))
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func display(path string) string {
+	if path == "" {
+		return "<root>"
+	}
+	return path
+}
+
+// encode returns the JSON encoding of a value, without escaping the angle
+// brackets of placeholders.
+func encode(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,67 @@
+package fixture
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want string
+		diffs     []string
+	}{
+		{
+			name: "id ignored",
+			got:  `{"jsonrpc":"2.0","id":7,"result":"0x3"}`,
+			want: `{"jsonrpc":"2.0","id":1,"result":"0x3"}`,
+		},
+		{
+			name:  "nested id compared",
+			got:   `{"jsonrpc":"2.0","id":1,"result":{"id":"0x2"}}`,
+			want:  `{"jsonrpc":"2.0","id":1,"result":{"id":"0x1"}}`,
+			diffs: []string{`result.id: got "0x2", want "0x1"`},
+		},
+		{
+			name:  "different value",
+			got:   `{"jsonrpc":"2.0","id":1,"result":"0x4"}`,
+			want:  `{"jsonrpc":"2.0","id":1,"result":"0x3"}`,
+			diffs: []string{`result: got "0x4", want "0x3"`},
+		},
+		{
+			name:  "missing and unexpected members",
+			got:   `{"jsonrpc":"2.0","id":1,"result":{"b":1}}`,
+			want:  `{"jsonrpc":"2.0","id":1,"result":{"a":1}}`,
+			diffs: []string{`result.a: missing (want: 1)`, `result.b: unexpected (got: 1)`},
+		},
+		{
+			name:  "array length",
+			got:   `{"jsonrpc":"2.0","id":1,"result":[1]}`,
+			want:  `{"jsonrpc":"2.0","id":1,"result":[1,2]}`,
+			diffs: []string{`result: length mismatch (got: 1, want: 2)`},
+		},
+		{
+			name:  "array element",
+			got:   `{"jsonrpc":"2.0","id":1,"result":[1,3]}`,
+			want:  `{"jsonrpc":"2.0","id":1,"result":[1,2]}`,
+			diffs: []string{`result[1]: got 3, want 2`},
+		},
+		{
+			name:  "root type",
+			got:   `[]`,
+			want:  `{"jsonrpc":"2.0","id":1,"result":"0x3"}`,
+			diffs: []string{`<root>: got [], want {"jsonrpc":"2.0","result":"0x3"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := Diff([]byte(tt.got), []byte(tt.want))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(diffs, tt.diffs) {
+				t.Errorf("wrong diffs:\ngot  %q\nwant %q", diffs, tt.diffs)
+			}
+		})
+	}
+}
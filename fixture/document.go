@@ -0,0 +1,236 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Fixture formats. The format of a fixture file is also its extension.
+const (
+	FormatIO   = "io"   // line based format
+	FormatJSON = "json" // structured JSON document
+)
+
+// CheckFormat returns an error if the fixture format is not supported.
+func CheckFormat(format string) error {
+	switch format {
+	case FormatIO, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown fixture format: %s", format)
+	}
+}
+
+// Document is a fixture in the JSON format. Unlike the line based format, it
+// records the expectations of the test next to the exchanges they apply to.
+type Document struct {
+	Name      string         `json:"name"`
+	About     string         `json:"about"`
+	Method    string         `json:"method"`
+	Chain     string         `json:"chain"`
+	Client    string         `json:"client,omitempty"`    // version of the client that filled the fixture
+	Head      string         `json:"head,omitempty"`      // hash of the head block of the test chain
+	Generator string         `json:"generator,omitempty"` // version of rpctestgen that filled the fixture
+	Mutates   bool           `json:"mutates,omitempty"`   // whether the test changes the state of the client
+	Exchanges []*DocExchange `json:"exchanges"`
+}
+
+// DocExchange is an exchange of a Document. The request of notifications is
+// omitted.
+type DocExchange struct {
+	Request      json.RawMessage `json:"request,omitempty"`
+	Response     json.RawMessage `json:"response"`
+	Expectations *Expectations   `json:"expectations,omitempty"`
+}
+
+// Expectations are the parts of a test's expectations that apply to a single
+// exchange.
+type Expectations struct {
+	Error     *ExpectedError `json:"error,omitempty"`
+	Normalize []Rule         `json:"normalize,omitempty"`
+}
+
+// NewDocument converts a fixture of the named test of a method and chain into
+// a document. The expected error is attached to the exchanges answered with an
+// error, each rule to the exchanges of the method it is limited to. Rules
+// limited to a method without exchanges don't apply to the fixture and are
+// dropped.
+func NewDocument(f *Fixture, chain, method, name string) *Document {
+	h := f.Header()
+	doc := &Document{
+		Name:      name,
+		About:     h.About,
+		Method:    method,
+		Chain:     chain,
+		Client:    h.Client,
+		Head:      h.Head,
+		Generator: h.Generator,
+		Mutates:   h.Mutates,
+		Exchanges: make([]*DocExchange, 0, len(f.Exchanges)),
+	}
+	for _, ex := range f.Exchanges {
+		var (
+			dex     = &DocExchange{Request: ex.Request, Response: ex.Response}
+			exp     Expectations
+			methods = ex.Methods()
+		)
+		if h.Error != nil && hasError(ex.Response) {
+			exp.Error = h.Error
+		}
+		for _, r := range h.Normalize {
+			if r.Method == "" || slices.Contains(methods, r.Method) {
+				exp.Normalize = append(exp.Normalize, r)
+			}
+		}
+		if exp.Error != nil || len(exp.Normalize) != 0 {
+			dex.Expectations = &exp
+		}
+		doc.Exchanges = append(doc.Exchanges, dex)
+	}
+	return doc
+}
+
+// Fixture converts the document into a fixture. The messages are compacted to
+// a single line and the expectations of the exchanges are merged into the
+// header.
+func (doc *Document) Fixture() (*Fixture, error) {
+	h := &Header{
+		About:     doc.About,
+		Client:    doc.Client,
+		Head:      doc.Head,
+		Generator: doc.Generator,
+		Mutates:   doc.Mutates,
+	}
+	var (
+		f    = &Fixture{Exchanges: make([]*Exchange, 0, len(doc.Exchanges))}
+		seen = make(map[Rule]bool)
+	)
+	for _, dex := range doc.Exchanges {
+		ex := &Exchange{Request: compactMessage(dex.Request), Response: compactMessage(dex.Response)}
+		f.Exchanges = append(f.Exchanges, ex)
+		if dex.Expectations == nil {
+			continue
+		}
+		if h.Error == nil {
+			h.Error = dex.Expectations.Error
+		}
+		for _, r := range dex.Expectations.Normalize {
+			if !seen[r] {
+				seen[r] = true
+				h.Normalize = append(h.Normalize, r)
+			}
+		}
+	}
+	comments, err := headerComments(h)
+	if err != nil {
+		return nil, err
+	}
+	f.Comments = comments
+	return f, nil
+}
+
+// ParseDocument parses a fixture in the JSON format.
+func ParseDocument(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for i, dex := range doc.Exchanges {
+		if len(dex.Response) == 0 {
+			return nil, fmt.Errorf("exchange %d: missing response", i)
+		}
+	}
+	return &doc, nil
+}
+
+// WriteDocument writes the document to w as indented JSON.
+func WriteDocument(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Write writes the fixture to w in the line based format.
+func (f *Fixture) Write(w io.Writer) error {
+	for _, c := range f.Comments {
+		if _, err := fmt.Fprintf(w, "// %s\n", c); err != nil {
+			return err
+		}
+	}
+	for _, ex := range f.Exchanges {
+		if ex.Request != nil {
+			if _, err := fmt.Fprintf(w, ">> %s\n", ex.Request); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "<< %s\n", ex.Response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFile writes the fixture of the named test of a method and chain to the
+// file in the format given by its extension.
+func (f *Fixture) WriteFile(filename, chain, method, name string) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if Format(filename) == FormatJSON {
+		err = WriteDocument(out, NewDocument(f, chain, method, name))
+	} else {
+		err = f.Write(out)
+	}
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Format returns the format of a fixture file, as given by its extension.
+func Format(filename string) string {
+	return strings.TrimPrefix(filepath.Ext(filename), ".")
+}
+
+// compactMessage removes insignificant whitespace from a JSON message.
+func compactMessage(msg json.RawMessage) json.RawMessage {
+	if msg == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, msg); err != nil {
+		return msg
+	}
+	return buf.Bytes()
+}
+
+// hasError reports whether the response, or any response of a batch, is an
+// error.
+func hasError(resp json.RawMessage) bool {
+	type message struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if !isArray(resp) {
+		var msg message
+		return json.Unmarshal(resp, &msg) == nil && msg.Error != nil
+	}
+	var msgs []message
+	if err := json.Unmarshal(resp, &msgs); err != nil {
+		return false
+	}
+	for _, msg := range msgs {
+		if msg.Error != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package fixture
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name: "simple",
+			input: `// retrieves the client's current block number
+// client: Geth/v1.11.4-stable/linux-amd64/go1.20.2
+// head: 0x6b3c6b0b7e4b7c6a7c3c0e5f2d1f0e4d2c1b0a9f8e7d6c5b4a39281706f5e4d3
+// rpctestgen: 5e5f0c8c2b0a1e0d5d8a3a4c9f1e2b3c4d5e6f70
+>> {"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}
+<< {"jsonrpc":"2.0","id":1,"result":"0x3"}
+`,
+		},
+		{
+			name: "expectations",
+			input: `// gets block with invalid number formatting
+// spanning two lines
+// error: {"code":-32602}
+// mutates: true
+// normalize: ignore error.message
+// normalize: schema result eth_newFilter
+>> {"jsonrpc":"2.0","id":1,"method":"eth_newFilter","params":[{}]}
+<< {"jsonrpc":"2.0","id":1,"result":"0x1"}
+>> {"jsonrpc":"2.0","id":2,"method":"debug_getRawBlock","params":["2"]}
+<< {"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"invalid argument 0: hex string without 0x prefix"}}
+`,
+		},
+		{
+			name: "notification and batch",
+			input: `// subscribes to pending transactions
+// normalize: schema result eth_subscribe
+// normalize: schema params.subscription eth_subscription
+>> {"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newPendingTransactions"]}
+<< {"jsonrpc":"2.0","id":1,"result":"0x1"}
+<< {"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":"0x2"}}
+>> [{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":3,"method":"eth_chainId"}]
+<< [{"jsonrpc":"2.0","id":2,"result":"0x3"},{"jsonrpc":"2.0","id":3,"result":"0x539"}]
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unable to parse fixture: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := WriteDocument(&buf, NewDocument(f, "simple", "eth_blockNumber", tt.name)); err != nil {
+				t.Fatalf("unable to write document: %v", err)
+			}
+			doc, err := ParseDocument(buf.Bytes())
+			if err != nil {
+				t.Fatalf("unable to parse document: %v\n%s", err, buf.Bytes())
+			}
+			if doc.Chain != "simple" || doc.Method != "eth_blockNumber" || doc.Name != tt.name {
+				t.Errorf("wrong test of document: %s/%s/%s", doc.Chain, doc.Method, doc.Name)
+			}
+			back, err := doc.Fixture()
+			if err != nil {
+				t.Fatalf("unable to convert document: %v", err)
+			}
+			var out bytes.Buffer
+			if err := back.Write(&out); err != nil {
+				t.Fatalf("unable to write fixture: %v", err)
+			}
+			if out.String() != tt.input {
+				t.Errorf("round trip changed the fixture:\ngot:\n%s\nwant:\n%s", out.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestDocumentDropsUnusedRules(t *testing.T) {
+	f, err := Parse([]byte(`// normalize: quantity result eth_gasPrice
+>> {"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}
+<< {"jsonrpc":"2.0","id":1,"result":"0x3"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := NewDocument(f, "simple", "eth_blockNumber", "simple-test")
+	if exp := doc.Exchanges[0].Expectations; exp != nil {
+		t.Errorf("unexpected expectations: %+v", exp)
+	}
+}
@@ -0,0 +1,239 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Exchange is a single request and response pair recorded in a fixture. For
+// notifications pushed by the server, such as subscription updates, Request
+// is nil.
+type Exchange struct {
+	Request  json.RawMessage
+	Response json.RawMessage
+}
+
+// IsBatch reports whether the request of the exchange is a batch, i.e. an
+// array of calls.
+func (ex *Exchange) IsBatch() bool {
+	return isArray(ex.Request)
+}
+
+// Calls splits the exchange into a request and response pair per call. An
+// exchange that isn't a batch is returned as is.
+//
+// The calls of a batch are matched with their responses by id, so the server
+// may answer them in any order. Notifications in the batch don't get a
+// response and are left out. Responses which can't be matched with a call,
+// e.g. errors for invalid calls, are returned with a nil Request. An empty
+// batch has no calls, the server answers it with a single error.
+func (ex *Exchange) Calls() ([]*Exchange, error) {
+	if !ex.IsBatch() {
+		return []*Exchange{ex}, nil
+	}
+	var reqs []json.RawMessage
+	if err := json.Unmarshal(ex.Request, &reqs); err != nil {
+		return nil, fmt.Errorf("invalid batch request: %w", err)
+	}
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	if !isArray(ex.Response) {
+		return nil, fmt.Errorf("batch answered with a single response: %s", ex.Response)
+	}
+	var resps []json.RawMessage
+	if err := json.Unmarshal(ex.Response, &resps); err != nil {
+		return nil, fmt.Errorf("invalid batch response: %w", err)
+	}
+
+	// Index the responses by id.
+	var (
+		byID  = make(map[string]json.RawMessage, len(resps))
+		calls = make([]*Exchange, 0, len(reqs))
+		other []*Exchange
+	)
+	for _, resp := range resps {
+		id := messageID(resp)
+		if id == "" || id == "null" {
+			other = append(other, &Exchange{Response: resp})
+			continue
+		}
+		if _, ok := byID[id]; ok {
+			return nil, fmt.Errorf("duplicate response id %s in batch", id)
+		}
+		byID[id] = resp
+	}
+	for _, req := range reqs {
+		id := messageID(req)
+		if id == "" || id == "null" {
+			// Notifications don't get a response. The response to a
+			// call with a null id can't be told apart from other
+			// unmatched responses.
+			continue
+		}
+		resp, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("missing response to call with id %s in batch", id)
+		}
+		delete(byID, id)
+		calls = append(calls, &Exchange{Request: req, Response: resp})
+	}
+	if len(byID) != 0 {
+		return nil, fmt.Errorf("batch contains %d responses without call", len(byID))
+	}
+	return append(calls, other...), nil
+}
+
+// Methods returns the methods called by the exchange, or the method of a
+// notification.
+func (ex *Exchange) Methods() []string {
+	if !ex.IsBatch() {
+		return []string{exchangeMethod(ex)}
+	}
+	var calls []struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(ex.Request, &calls); err != nil {
+		return nil
+	}
+	methods := make([]string, len(calls))
+	for i, c := range calls {
+		methods[i] = c.Method
+	}
+	return methods
+}
+
+// Fixture is a parsed test fixture.
+type Fixture struct {
+	Comments  []string
+	Exchanges []*Exchange
+}
+
+// ReadFile reads and parses the fixture stored at filename, in the format
+// given by its extension.
+func ReadFile(filename string) (*Fixture, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if Format(filename) != FormatJSON {
+		return Parse(data)
+	}
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Fixture()
+}
+
+// Parse parses a fixture in the line based format, where a request is
+// prefixed with ">> " and its response with "<< ".
+func Parse(data []byte) (*Fixture, error) {
+	var (
+		f   = &Fixture{}
+		req json.RawMessage
+	)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case len(line) == 0:
+			// Skip blank lines.
+			continue
+		case strings.HasPrefix(line, "//"):
+			f.Comments = append(f.Comments, strings.TrimSpace(line[2:]))
+		case strings.HasPrefix(line, ">> "):
+			if req != nil {
+				return nil, fmt.Errorf("request w/o corresponding response")
+			}
+			req = json.RawMessage(line[3:])
+			if !json.Valid(req) {
+				return nil, fmt.Errorf("invalid request: %s", req)
+			}
+		case strings.HasPrefix(line, "<< "):
+			resp := json.RawMessage(line[3:])
+			if !json.Valid(resp) {
+				return nil, fmt.Errorf("invalid response: %s", resp)
+			}
+			// Notifications may arrive at any point, even while a request
+			// is awaiting its response.
+			if isNotification(resp) {
+				f.Exchanges = append(f.Exchanges, &Exchange{Response: resp})
+				continue
+			}
+			if req == nil {
+				return nil, fmt.Errorf("response w/o corresponding request")
+			}
+			f.Exchanges = append(f.Exchanges, &Exchange{Request: req, Response: resp})
+			req = nil
+		default:
+			return nil, fmt.Errorf("invalid line in test: %s", line)
+		}
+	}
+	if req != nil {
+		return nil, fmt.Errorf("unhandled request")
+	}
+	return f, nil
+}
+
+// isNotification reports whether msg is a notification sent by the server,
+// i.e. a message with a method but without an id.
+func isNotification(msg json.RawMessage) bool {
+	var m struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return false
+	}
+	return m.ID == nil && m.Method != ""
+}
+
+// messageID returns the encoded id of a JSON-RPC message, or an empty string
+// if it has none.
+func messageID(msg json.RawMessage) string {
+	var m struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return ""
+	}
+	return string(m.ID)
+}
+
+// isArray reports whether msg is a JSON array.
+func isArray(msg json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(msg)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// Walk walks a root directory and calls fn for every fixture file, in either
+// format, whose name matches the regular expression. The name passed to fn is
+// the path relative to root without the file extension.
+func Walk(root string, re *regexp.Regexp, fn func(name, path string) error) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("unable to walk path: %w", err)
+		}
+		if info.IsDir() {
+			return nil
+		}
+		// The genesis is stored next to the fixtures of its chain.
+		if info.Name() == "genesis.json" {
+			return nil
+		}
+		format := Format(path)
+		if CheckFormat(format) != nil {
+			return nil
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(path, root), "."+format)
+		if !re.MatchString(name) {
+			return nil // skip
+		}
+		return fn(name, path)
+	})
+}
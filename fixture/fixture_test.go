@@ -0,0 +1,105 @@
+package fixture
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		comments  []string
+		exchanges [][2]string // request and response, the request is empty for notifications
+		err       string
+	}{
+		{
+			name: "comments",
+			input: `// retrieves the client's current block number
+//   client: Geth
+
+>> {"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}
+<< {"jsonrpc":"2.0","id":1,"result":"0x3"}
+`,
+			comments: []string{"retrieves the client's current block number", "client: Geth"},
+			exchanges: [][2]string{
+				{`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`, `{"jsonrpc":"2.0","id":1,"result":"0x3"}`},
+			},
+		},
+		{
+			name: "notification",
+			input: `>> {"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]}
+<< {"jsonrpc":"2.0","id":1,"result":"0x1"}
+>> {"jsonrpc":"2.0","id":2,"method":"eth_sendRawTransaction","params":["0x00"]}
+<< {"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":"0x2"}}
+<< {"jsonrpc":"2.0","id":2,"result":"0x3"}
+`,
+			exchanges: [][2]string{
+				{`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]}`, `{"jsonrpc":"2.0","id":1,"result":"0x1"}`},
+				{``, `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":"0x2"}}`},
+				{`{"jsonrpc":"2.0","id":2,"method":"eth_sendRawTransaction","params":["0x00"]}`, `{"jsonrpc":"2.0","id":2,"result":"0x3"}`},
+			},
+		},
+		{
+			name: "batch",
+			input: `>> [{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}]
+<< [{"jsonrpc":"2.0","id":1,"result":"0x3"}]
+`,
+			exchanges: [][2]string{
+				{`[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}]`, `[{"jsonrpc":"2.0","id":1,"result":"0x3"}]`},
+			},
+		},
+		{
+			name:  "request without response",
+			input: `>> {"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`,
+			err:   "unhandled request",
+		},
+		{
+			name: "consecutive requests",
+			input: `>> {"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}
+>> {"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"}
+<< {"jsonrpc":"2.0","id":2,"result":"0x3"}
+`,
+			err: "request w/o corresponding response",
+		},
+		{
+			name:  "response without request",
+			input: `<< {"jsonrpc":"2.0","id":1,"result":"0x3"}`,
+			err:   "response w/o corresponding request",
+		},
+		{
+			name:  "invalid request",
+			input: `>> {"jsonrpc":"2.0",`,
+			err:   `invalid request: {"jsonrpc":"2.0",`,
+		},
+		{
+			name:  "invalid line",
+			input: `result: 0x3`,
+			err:   "invalid line in test: result: 0x3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.input))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("wrong error: got %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(f.Comments, tt.comments) {
+				t.Errorf("wrong comments: got %q, want %q", f.Comments, tt.comments)
+			}
+			var exchanges [][2]string
+			for _, ex := range f.Exchanges {
+				exchanges = append(exchanges, [2]string{string(ex.Request), string(ex.Response)})
+			}
+			if !reflect.DeepEqual(exchanges, tt.exchanges) {
+				t.Errorf("wrong exchanges:\ngot  %q\nwant %q", exchanges, tt.exchanges)
+			}
+		})
+	}
+}
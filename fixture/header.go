@@ -0,0 +1,132 @@
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Header is the metadata recorded as comments at the top of a fixture.
+type Header struct {
+	About     string // description of the test
+	Client    string // version of the client that filled the fixture
+	Head      string // hash of the head block of the test chain
+	Generator string // version of rpctestgen that filled the fixture
+
+	// Error is set if the test expects the client to respond with an error.
+	Error *ExpectedError
+
+	// Mutates is set if the test changes the state of the client, so that
+	// the fixture can't be replayed after another such fixture without
+	// resetting the client.
+	Mutates bool
+
+	// Normalize lists the values of the responses which aren't compared
+	// exactly.
+	Normalize []Rule
+}
+
+// ExpectedError is the JSON-RPC error expected by a negative test. A zero code
+// accepts any error code, a nil data any error data.
+type ExpectedError struct {
+	Code int         `json:"code,omitempty"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Header comment keys.
+const (
+	keyClient    = "client"
+	keyHead      = "head"
+	keyGenerator = "rpctestgen"
+	keyError     = "error"
+	keyMutates   = "mutates"
+	keyNormalize = "normalize"
+)
+
+// WriteHeader writes the header to w as comment lines.
+func WriteHeader(w io.Writer, h *Header) error {
+	lines, err := headerComments(h)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(w, "// %s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// headerComments returns the comments the header is recorded as.
+func headerComments(h *Header) ([]string, error) {
+	var expected string
+	if h.Error != nil {
+		buf, err := json.Marshal(h.Error)
+		if err != nil {
+			return nil, err
+		}
+		expected = string(buf)
+	}
+	var mutates string
+	if h.Mutates {
+		mutates = "true"
+	}
+	// A description spanning several lines is written as a comment per line.
+	lines := strings.Split(h.About, "\n")
+	for _, kv := range [][2]string{
+		{keyClient, h.Client},
+		{keyHead, h.Head},
+		{keyGenerator, h.Generator},
+		{keyError, expected},
+		{keyMutates, mutates},
+	} {
+		if kv[1] != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", kv[0], kv[1]))
+		}
+	}
+	for _, rule := range h.Normalize {
+		lines = append(lines, fmt.Sprintf("%s: %s", keyNormalize, rule))
+	}
+	return lines, nil
+}
+
+// Header parses the metadata from the fixture's comments. Comments that are
+// not metadata are treated as the lines of the test's description.
+func (f *Fixture) Header() *Header {
+	var (
+		h     Header
+		about []string
+	)
+	for _, c := range f.Comments {
+		key, value, _ := strings.Cut(c, ": ")
+		switch key {
+		case keyClient:
+			h.Client = value
+		case keyHead:
+			h.Head = value
+		case keyGenerator:
+			h.Generator = value
+		case keyError:
+			var e ExpectedError
+			if err := json.Unmarshal([]byte(value), &e); err != nil {
+				about = append(about, c)
+				continue
+			}
+			h.Error = &e
+		case keyMutates:
+			h.Mutates = value == "true"
+		case keyNormalize:
+			rule, err := ParseRule(value)
+			if err != nil {
+				about = append(about, c)
+				continue
+			}
+			h.Normalize = append(h.Normalize, rule)
+		default:
+			about = append(about, c)
+		}
+	}
+	h.About = strings.Join(about, "\n")
+	return &h
+}
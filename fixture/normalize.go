@@ -0,0 +1,274 @@
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Normalization rule kinds.
+const (
+	RuleIgnore   = "ignore"   // the value may differ or be missing
+	RuleSchema   = "schema"   // the value only has to match the schema of the method
+	RuleQuantity = "quantity" // the value may be any hex encoded quantity
+)
+
+// Placeholders which replace normalized values.
+const (
+	schemaPlaceholder   = "<schema>"
+	quantityPlaceholder = "<quantity>"
+	ignoredPlaceholder  = "<ignored>"
+)
+
+var quantityRegexp = regexp.MustCompile(`^0x(0|[1-9a-f][0-9a-f]*)$`)
+
+// Rule marks the values of the responses of a fixture which legitimately vary
+// between clients or runs, so that they aren't compared exactly.
+//
+// The path selects values by the dot-separated member names or array indices
+// leading to them from the top of the response, e.g. error.message. A *
+// matches any member or element. If the method is set, the rule only applies
+// to responses to calls of the method and to notifications of it.
+type Rule struct {
+	Kind   string `json:"kind"`
+	Path   string `json:"path"`
+	Method string `json:"method,omitempty"`
+}
+
+// ParseRule parses a rule in the format written to fixtures, i.e. the kind,
+// the path and optionally the method separated by spaces.
+func ParseRule(s string) (Rule, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || len(fields) > 3 {
+		return Rule{}, fmt.Errorf("invalid rule: %q", s)
+	}
+	r := Rule{Kind: fields[0], Path: fields[1]}
+	if len(fields) == 3 {
+		r.Method = fields[2]
+	}
+	switch r.Kind {
+	case RuleIgnore, RuleSchema, RuleQuantity:
+	default:
+		return Rule{}, fmt.Errorf("unknown rule kind: %s", r.Kind)
+	}
+	return r, nil
+}
+
+// For limits the rule to responses to calls of the method and to its
+// notifications.
+func (r Rule) For(method string) Rule {
+	r.Method = method
+	return r
+}
+
+// String returns the rule in the format written to fixtures.
+func (r Rule) String() string {
+	if r.Method == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Path)
+	}
+	return fmt.Sprintf("%s %s %s", r.Kind, r.Path, r.Method)
+}
+
+// Normalize returns the response of the exchange with every value selected by
+// the rules replaced by a placeholder, or removed if it is ignored. Quantity
+// rules only replace valid quantities, so that other values still show up as a
+// difference. The responses of a batch are normalized by the method of the
+// call with the same id, a single response to a batch by the rules for any
+// method.
+func Normalize(ex *Exchange, rules []Rule) (json.RawMessage, error) {
+	if len(rules) == 0 {
+		return ex.Response, nil
+	}
+	var resp interface{}
+	if err := json.Unmarshal(ex.Response, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if ex.IsBatch() {
+		methods, err := batchMethods(ex.Request)
+		if err != nil {
+			return nil, err
+		}
+		if elems, ok := resp.([]interface{}); ok {
+			for i, elem := range elems {
+				id, _ := json.Marshal(memberOf(elem, "id"))
+				elems[i] = normalizeValue(elem, methods[string(id)], rules)
+			}
+		} else {
+			// A batch rejected as a whole, e.g. an empty one, is answered
+			// with a single error, which only rules for any method apply
+			// to.
+			resp = normalizeValue(resp, "", rules)
+		}
+	} else {
+		resp = normalizeValue(resp, exchangeMethod(ex), rules)
+	}
+	return json.Marshal(resp)
+}
+
+// DiffNormalized compares the responses of two exchanges like Diff, after
+// normalizing both with the rules.
+func DiffNormalized(got, want *Exchange, rules []Rule) ([]string, error) {
+	g, err := Normalize(got, rules)
+	if err != nil {
+		return nil, fmt.Errorf("got: %w", err)
+	}
+	w, err := Normalize(want, rules)
+	if err != nil {
+		return nil, fmt.Errorf("want: %w", err)
+	}
+	return Diff(g, w)
+}
+
+// CheckRules returns a description of every value in the response of the
+// exchange which violates a rule, i.e. which is selected by a quantity rule but
+// isn't a quantity. Batches must be split into their calls first.
+func CheckRules(ex *Exchange, rules []Rule) ([]string, error) {
+	var resp interface{}
+	if err := json.Unmarshal(ex.Response, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	method := exchangeMethod(ex)
+	var out []string
+	for _, r := range rules {
+		if r.Kind != RuleQuantity || !r.appliesTo(method) {
+			continue
+		}
+		apply(resp, "", splitPath(r.Path), func(path string, v interface{}) (interface{}, bool) {
+			if !isQuantity(v) {
+				out = append(out, fmt.Sprintf("%s: not a quantity: %s", path, encode(v)))
+			}
+			return v, true
+		})
+	}
+	return out, nil
+}
+
+func (r Rule) appliesTo(method string) bool {
+	return r.Method == "" || r.Method == method
+}
+
+// normalizeValue applies the rules of the method to a decoded response.
+func normalizeValue(resp interface{}, method string, rules []Rule) interface{} {
+	for _, r := range rules {
+		if !r.appliesTo(method) {
+			continue
+		}
+		kind := r.Kind
+		resp = apply(resp, "", splitPath(r.Path), func(path string, v interface{}) (interface{}, bool) {
+			switch kind {
+			case RuleIgnore:
+				return ignoredPlaceholder, false
+			case RuleSchema:
+				return schemaPlaceholder, true
+			case RuleQuantity:
+				if isQuantity(v) {
+					return quantityPlaceholder, true
+				}
+			}
+			return v, true
+		})
+	}
+	return resp
+}
+
+// apply calls fn for every value matched by the path and replaces it with the
+// returned value. Members of objects are removed if fn doesn't keep them,
+// elements of arrays are replaced regardless.
+func apply(v interface{}, prefix string, path []string, fn func(string, interface{}) (interface{}, bool)) interface{} {
+	if len(path) == 0 {
+		return v
+	}
+	seg, rest := path[0], path[1:]
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range v {
+			if seg != "*" && seg != k {
+				continue
+			}
+			p := join(prefix, k)
+			if len(rest) != 0 {
+				v[k] = apply(elem, p, rest, fn)
+				continue
+			}
+			if nv, keep := fn(p, elem); keep {
+				v[k] = nv
+			} else {
+				delete(v, k)
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			if seg != "*" && seg != strconv.Itoa(i) {
+				continue
+			}
+			p := fmt.Sprintf("%s[%d]", prefix, i)
+			if len(rest) != 0 {
+				v[i] = apply(elem, p, rest, fn)
+				continue
+			}
+			v[i], _ = fn(p, elem)
+		}
+	}
+	return v
+}
+
+func splitPath(path string) []string {
+	return strings.Split(path, ".")
+}
+
+func isQuantity(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && quantityRegexp.MatchString(s)
+}
+
+// exchangeMethod returns the method called by the request of the exchange or,
+// for notifications, the method of the notification.
+func exchangeMethod(ex *Exchange) string {
+	msg := ex.Request
+	if msg == nil {
+		msg = ex.Response
+	}
+	var m struct {
+		Method string `json:"method"`
+	}
+	json.Unmarshal(msg, &m)
+	return m.Method
+}
+
+// batchMethods maps the encoded ids of the calls of a batch to their methods.
+func batchMethods(req json.RawMessage) (map[string]string, error) {
+	var calls []struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(req, &calls); err != nil {
+		return nil, fmt.Errorf("invalid batch request: %w", err)
+	}
+	methods := make(map[string]string, len(calls))
+	for _, c := range calls {
+		if c.ID != nil {
+			methods[string(compactJSON(c.ID))] = c.Method
+		}
+	}
+	return methods, nil
+}
+
+func memberOf(v interface{}, key string) interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m[key]
+	}
+	return nil
+}
+
+// compactJSON re-encodes a JSON value, so that it can be compared with values
+// encoded by encoding/json.
+func compactJSON(msg json.RawMessage) []byte {
+	var v interface{}
+	if err := json.Unmarshal(msg, &v); err != nil {
+		return msg
+	}
+	buf, _ := json.Marshal(v)
+	return buf
+}
@@ -0,0 +1,174 @@
+package fixture
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  string // empty for notifications
+		response string
+		rules    []Rule
+		want     string
+	}{
+		{
+			name:     "no rules",
+			request:  `{"jsonrpc":"2.0","id":1,"method":"eth_gasPrice"}`,
+			response: `{"jsonrpc":"2.0","id":1,"result":"0x3"}`,
+			want:     `{"jsonrpc":"2.0","id":1,"result":"0x3"}`,
+		},
+		{
+			name:     "ignore",
+			request:  `{"jsonrpc":"2.0","id":1,"method":"eth_call"}`,
+			response: `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted"}}`,
+			rules:    []Rule{{Kind: RuleIgnore, Path: "error.message"}},
+			want:     `{"error":{"code":3},"id":1,"jsonrpc":"2.0"}`,
+		},
+		{
+			name:     "schema",
+			request:  `{"jsonrpc":"2.0","id":1,"method":"eth_newFilter"}`,
+			response: `{"jsonrpc":"2.0","id":1,"result":"0x530880875f11ae9c6ad61316d46a2382"}`,
+			rules:    []Rule{{Kind: RuleSchema, Path: "result"}},
+			want:     `{"id":1,"jsonrpc":"2.0","result":"<schema>"}`,
+		},
+		{
+			name:     "quantity",
+			request:  `{"jsonrpc":"2.0","id":1,"method":"eth_gasPrice"}`,
+			response: `{"jsonrpc":"2.0","id":1,"result":"0x3b9aca00"}`,
+			rules:    []Rule{{Kind: RuleQuantity, Path: "result"}},
+			want:     `{"id":1,"jsonrpc":"2.0","result":"<quantity>"}`,
+		},
+		{
+			name:     "invalid quantity",
+			request:  `{"jsonrpc":"2.0","id":1,"method":"eth_gasPrice"}`,
+			response: `{"jsonrpc":"2.0","id":1,"result":"0x03"}`,
+			rules:    []Rule{{Kind: RuleQuantity, Path: "result"}},
+			want:     `{"id":1,"jsonrpc":"2.0","result":"0x03"}`,
+		},
+		{
+			name:     "wildcard",
+			request:  `{"jsonrpc":"2.0","id":1,"method":"eth_feeHistory"}`,
+			response: `{"jsonrpc":"2.0","id":1,"result":{"reward":[["0x1","0x2"],["0x3"]]}}`,
+			rules:    []Rule{{Kind: RuleQuantity, Path: "result.reward.*.0"}},
+			want:     `{"id":1,"jsonrpc":"2.0","result":{"reward":[["<quantity>","0x2"],["<quantity>"]]}}`,
+		},
+		{
+			name:     "other method",
+			request:  `{"jsonrpc":"2.0","id":1,"method":"eth_gasPrice"}`,
+			response: `{"jsonrpc":"2.0","id":1,"result":"0x3"}`,
+			rules:    []Rule{{Kind: RuleQuantity, Path: "result", Method: "eth_maxPriorityFeePerGas"}},
+			want:     `{"id":1,"jsonrpc":"2.0","result":"0x3"}`,
+		},
+		{
+			name:     "notification",
+			response: `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":"0x2"}}`,
+			rules:    []Rule{{Kind: RuleSchema, Path: "params.subscription", Method: "eth_subscription"}},
+			want:     `{"jsonrpc":"2.0","method":"eth_subscription","params":{"result":"0x2","subscription":"<schema>"}}`,
+		},
+		{
+			name:     "batch",
+			request:  `[{"jsonrpc":"2.0","id":1,"method":"eth_gasPrice"},{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"}]`,
+			response: `[{"jsonrpc":"2.0","id":2,"result":"0x3"},{"jsonrpc":"2.0","id":1,"result":"0x4"}]`,
+			rules:    []Rule{{Kind: RuleQuantity, Path: "result", Method: "eth_gasPrice"}},
+			want:     `[{"id":2,"jsonrpc":"2.0","result":"0x3"},{"id":1,"jsonrpc":"2.0","result":"<quantity>"}]`,
+		},
+		{
+			name:     "batch answered with a single error",
+			request:  `[]`,
+			response: `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"empty batch"}}`,
+			rules: []Rule{
+				{Kind: RuleIgnore, Path: "error.message"},
+				{Kind: RuleIgnore, Path: "error.code", Method: "eth_gasPrice"},
+			},
+			want: `{"error":{"code":-32600},"id":null,"jsonrpc":"2.0"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &Exchange{Response: []byte(tt.response)}
+			if tt.request != "" {
+				ex.Request = []byte(tt.request)
+			}
+			got, err := Normalize(ex, tt.rules)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var g, w interface{}
+			if err := json.Unmarshal(got, &g); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &w); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(g, w) {
+				t.Errorf("wrong response:\ngot  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffNormalized(t *testing.T) {
+	var (
+		rules = []Rule{{Kind: RuleIgnore, Path: "error.message"}}
+		got   = &Exchange{Request: []byte(`[]`), Response: []byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid request"}}`)}
+		want  = &Exchange{Request: []byte(`[]`), Response: []byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"empty batch"}}`)}
+	)
+	diffs, err := DiffNormalized(got, want, rules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("unexpected diffs: %q", diffs)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	var (
+		ex    = &Exchange{Request: []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_gasPrice"}`), Response: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x03"}`)}
+		rules = []Rule{{Kind: RuleQuantity, Path: "result"}, {Kind: RuleQuantity, Path: "result", Method: "eth_blockNumber"}}
+		want  = []string{`result: not a quantity: "0x03"`}
+	)
+	got, err := CheckRules(ex, rules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong violations: got %q, want %q", got, want)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Rule
+		err   bool
+	}{
+		{input: "ignore error.message", want: Rule{Kind: RuleIgnore, Path: "error.message"}},
+		{input: "schema result eth_newFilter", want: Rule{Kind: RuleSchema, Path: "result", Method: "eth_newFilter"}},
+		{input: "quantity", err: true},
+		{input: "exact result", err: true},
+		{input: "ignore result eth_call extra", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: expected error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %+v, want %+v", tt.input, got, tt.want)
+		}
+		if s := got.String(); s != tt.input {
+			t.Errorf("%q: wrong string %q", tt.input, s)
+		}
+	}
+}
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/beacon"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
@@ -16,16 +23,128 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/lightclient/rpctestgen/fixture"
+	"github.com/lightclient/rpctestgen/report"
 	"github.com/lightclient/rpctestgen/testgen"
 )
 
-// runGenerator generates test fixtures against the specified client and writes
-// them to the output directory.
+// runGenerator generates test fixtures against the specified client, or the
+// clients to compare in diff mode, and writes them to the output directory. The
+// report of either mode is written even if the run fails after filling.
 func runGenerator(ctx context.Context) error {
-	args := ctx.Value(ARGS).(*Args)
+	var (
+		args = ctx.Value(ARGS).(*Args)
+		rep  *report.Report
+		err  error
+	)
+	if len(args.Diff) != 0 {
+		rep, err = runDiff(ctx, args)
+	} else {
+		rep, err = runFill(ctx, args)
+	}
+	if rep != nil && args.Report != "" {
+		if werr := rep.WriteFile(args.ReportFile, args.Report); err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// runFill fills the tests against the client and returns the report of the
+// filled tests.
+func runFill(ctx context.Context, args *Args) (*report.Report, error) {
+	chains, jobs, err := fillTests(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	rep := report.New("rpctestgen")
+	for _, name := range chains {
+		for _, job := range jobs[name] {
+			name := fmt.Sprintf("%s/%s", job.chain, job.test.Name)
+			if errors.Is(job.err, testgen.ErrSkip) {
+				rep.Skip(job.method, name, job.test.About, job.err.Error())
+				continue
+			}
+			rep.Add(job.method, name, job.test.About, job.duration, job.err)
+		}
+	}
+	return rep, nil
+}
+
+// fillTests fills the selected tests against the client and returns the names
+// of the chains they were filled on and the tests of each chain, in order.
+func fillTests(ctx context.Context, args *Args) ([]string, map[string][]*fillJob, error) {
+	// Collect the tests to fill, grouped by the chain they require. Store
+	// them in the format: outputDir/chainName/methodName/testName.format
+	var (
+		chains []string
+		jobs   = make(map[string][]*fillJob)
+	)
+	for _, methodTest := range testgen.AllMethods {
+		// Skip tests that don't match regexp.
+		if !args.tests.MatchString(methodTest.Name) {
+			continue
+		}
+		for i := range methodTest.Tests {
+			test := &methodTest.Tests[i]
+			chain := methodTest.ChainOf(test)
+			if !args.chainSelected(chain) {
+				continue
+			}
+			methodDir := fmt.Sprintf("%s/%s/%s", args.OutDir, chain, methodTest.Name)
+			if err := mkdir(methodDir); err != nil {
+				return nil, nil, err
+			}
+			if _, ok := jobs[chain]; !ok {
+				chains = append(chains, chain)
+			}
+			jobs[chain] = append(jobs[chain], &fillJob{
+				chain:    chain,
+				method:   methodTest.Name,
+				test:     test,
+				filename: fmt.Sprintf("%s/%s.%s", methodDir, test.Name, args.Format),
+			})
+		}
+	}
+	// Chains of definitions which no test requires are generated without
+	// filling any tests, so that clients can be run against them.
+	if args.ChainDir == "" {
+		names := make([]string, 0, len(args.chainDefs))
+		for name := range args.chainDefs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, ok := jobs[name]; ok || !args.chainSelected(name) {
+				continue
+			}
+			if _, err := initChain(ctx, args, name); err != nil {
+				return nil, nil, err
+			}
+			fmt.Printf("generated chain %s, no tests require it\n", name)
+		}
+	}
+	if args.ChainDir != "" && isFlatChainDir(args.ChainDir) && len(chains) > 1 {
+		return nil, nil, fmt.Errorf("%s holds a single chain, select one of %v with --chains", args.ChainDir, chains)
+	}
+	if args.ClientType == "external" && len(chains) > 1 {
+		return nil, nil, fmt.Errorf("external client serves a single chain, select one of %v with --chains", chains)
+	}
+
+	// Fill the tests of each chain against a fresh client.
+	for _, name := range chains {
+		if err := fillChain(ctx, args, name, jobs[name]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return chains, jobs, nil
+}
 
+// fillChain initializes the named chain, starts a client serving it and fills
+// the tests.
+func fillChain(ctx context.Context, args *Args, name string, jobs []*fillJob) error {
 	// Initialize generated chain.
-	chain, err := initChain(ctx, args)
+	chain, err := initChain(ctx, args, name)
 	if err != nil {
 		return err
 	}
@@ -37,93 +156,268 @@ func runGenerator(ctx context.Context) error {
 	}
 	defer client.Close()
 
-	// Generate test fixtures for all methods. Store them in the format:
-	// outputDir/methodName/testName.io
-	fmt.Println("filling tests...")
-	tests := testgen.AllMethods
-	for _, methodTest := range tests {
-		// Skip tests that don't match regexp.
-		if !args.tests.MatchString(methodTest.Name) {
+	// Gather the metadata recorded in the header of each fixture.
+	version, err := clientVersion(ctx, rpcEndpoint(args, client))
+	if err != nil {
+		return err
+	}
+	var (
+		head      = chain.bc.CurrentHeader().Hash().Hex()
+		generator = generatorVersion()
+	)
+
+	// Fill the tests. Read-only tests are filled concurrently, tests that
+	// mutate the client's state only once all previous tests are done and
+	// before any later one starts. The client is reset after each of them,
+	// so their changes don't leak into later tests. If the client can't be
+	// reset, only the first of them is filled.
+	fmt.Printf("filling tests of chain %s...\n", name)
+	var (
+		f = &filler{
+			args:   args,
+			client: client,
+			chain:  chain,
+			header: fixture.Header{Client: version, Head: head, Generator: generator},
+		}
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, args.Parallel)
+	)
+	for _, job := range jobs {
+		if job.test.Mutates {
+			wg.Wait()
+			f.fill(ctx, job)
+			if errors.Is(job.err, testgen.ErrSkip) {
+				continue
+			}
+			if err := f.reset(ctx); err != nil {
+				return err
+			}
 			continue
 		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(job *fillJob) {
+			defer wg.Done()
+			f.fill(ctx, job)
+			<-sem
+		}(job)
+	}
+	wg.Wait()
+	return nil
+}
 
-		methodDir := fmt.Sprintf("%s/%s", args.OutDir, methodTest.Name)
-		if err := mkdir(methodDir); err != nil {
-			return err
+// fillJob is a single test to fill and its outcome.
+type fillJob struct {
+	chain    string
+	method   string
+	test     *testgen.Test
+	filename string
+
+	duration time.Duration
+	err      error
+}
+
+// filler fills tests against a running client.
+type filler struct {
+	args   *Args
+	client Client
+	chain  *chainData
+	header fixture.Header // metadata shared by all fixtures
+	dirty  bool           // whether a test changed the state of a client which can't be reset
+}
+
+// fill fills the test and records the outcome in the job. Tests that change
+// the client's state fail without being run if an earlier test changed it
+// already, e.g. their transactions could collide with the earlier ones.
+func (f *filler) fill(ctx context.Context, job *fillJob) {
+	if job.test.Mutates && f.dirty {
+		job.err = fmt.Errorf("%w and an earlier test changed its state", errResetUnsupported)
+	} else {
+		job.err = f.fillTest(ctx, job)
+	}
+	if errors.Is(job.err, testgen.ErrSkip) {
+		// Don't leave a partial fixture behind.
+		os.Remove(job.filename)
+		fmt.Printf("generating %s skip.\n", job.filename)
+		fmt.Fprintf(os.Stderr, "skipped %s/%s: %s\n", job.method, job.test.Name, job.err)
+		return
+	}
+	if job.err != nil {
+		fmt.Printf("generating %s fail.\n", job.filename)
+		fmt.Fprintf(os.Stderr, "failed to fill %s/%s: %s\n", job.method, job.test.Name, job.err)
+		return
+	}
+	fmt.Printf("generating %s  done.\n", job.filename)
+}
+
+// reset restores the client to the state right after the chain import. If the
+// client doesn't support it, the client is marked dirty and a warning is
+// printed instead.
+func (f *filler) reset(ctx context.Context) error {
+	err := f.client.Reset()
+	if errors.Is(err, errResetUnsupported) {
+		if !f.dirty {
+			fmt.Fprintf(os.Stderr, "warning: %s, later tests that change the client's state fail and state changes may leak into read-only tests\n", err)
+			f.dirty = true
 		}
-		for _, test := range methodTest.Tests {
-			filename := fmt.Sprintf("%s/%s.io", methodDir, test.Name)
-			fmt.Printf("generating %s", filename)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to reset client: %w", err)
+	}
+	if err := f.client.Start(ctx, f.args.Verbose); err != nil {
+		return err
+	}
+	return waitForClient(ctx, rpcEndpoint(f.args, f.client), f.chain)
+}
 
-			// Connect ethclient to Ethereum client. This happens
-			// every test to force the json-rpc id to always be 0.
-			handler, err := newEthclientHandler(client.HttpAddr())
-			if err != nil {
-				return err
-			}
+func (f *filler) fillTest(ctx context.Context, job *fillJob) error {
+	// Connect ethclient to Ethereum client. This happens every test to
+	// force the json-rpc ids of each fixture to start from the same value
+	// and to give each test its own log.
+	var (
+		addr   = rpcEndpoint(f.args, f.client)
+		wsAddr = f.client.WsAddr()
+	)
+	if f.args.Transport == transportIPC {
+		// Subscriptions use a second connection to the socket.
+		wsAddr = addr
+	}
+	handler, err := newEthclientHandler(addr, wsAddr, f.client.AuthAddr(), f.args.jwtSecret)
+	if err != nil {
+		return err
+	}
+	defer handler.Close()
 
-			// Write the exchange for each test in a separte file.
-			handler.RotateLog(filename)
+	// Write the exchange for each test in a separate file. JSON fixtures are
+	// converted from the line based log once the test is done.
+	var log bytes.Buffer
+	if f.args.Format == fixture.FormatJSON {
+		handler.LogTo(&log)
+	} else if err := handler.RotateLog(job.filename); err != nil {
+		return err
+	}
+	header := f.header
+	header.About = job.test.About
+	header.Mutates = job.test.Mutates
+	header.Normalize = append(header.Normalize, job.test.Normalize...)
+	if e := job.test.ExpectError; e != nil {
+		header.Error = e
 
-			// Fail test fill if request exceeds timeout.
-			ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
-			defer cancel()
+		// Only the expected parts of the error are compared exactly.
+		header.Normalize = append(header.Normalize, fixture.Rule{Kind: fixture.RuleIgnore, Path: "error.message"})
+		if e.Code == 0 {
+			header.Normalize = append(header.Normalize, fixture.Rule{Kind: fixture.RuleIgnore, Path: "error.code"})
+		}
+		if e.Data == nil {
+			header.Normalize = append(header.Normalize, fixture.Rule{Kind: fixture.RuleIgnore, Path: "error.data"})
+		}
+	}
+	if err := fixture.WriteHeader(handler.log, &header); err != nil {
+		return err
+	}
 
-			err = test.Run(ctx, testgen.NewT(handler.ethclient, handler.gethclient, handler.rpc, chain.bc))
-			if err != nil {
-				fmt.Println(" fail.")
-				fmt.Fprintf(os.Stderr, "failed to fill %s/%s: %s\n", methodTest.Name, test.Name, err)
-				continue
-			}
-			fmt.Println("  done.")
-			handler.Close()
+	// Fail test fill if request exceeds timeout.
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// External clients may not share the filesystem, e.g. if they run in a
+	// container or on another host, so they aren't asked to read files.
+	badFile := f.chain.badFile
+	if f.args.ClientType == "external" {
+		badFile = ""
+	}
+	start := time.Now()
+	err = job.test.Execute(ctx, testgen.NewT(handler.ethclient, handler.gethclient, handler.rpc, handler.ws, handler.engine, handler.Send, f.chain.bc, f.chain.bad, badFile))
+	job.duration = time.Since(start)
+	if f.args.Format == fixture.FormatJSON && !errors.Is(err, testgen.ErrSkip) {
+		// Close the connections, so that nothing is logged anymore.
+		handler.Close()
+		if werr := writeDocument(job, log.Bytes()); err == nil {
+			err = werr
 		}
 	}
-	return nil
+	return err
+}
+
+// writeDocument converts the log of a test into a JSON fixture.
+func writeDocument(job *fillJob, log []byte) error {
+	f, err := fixture.Parse(log)
+	if err != nil {
+		return fmt.Errorf("unable to parse log: %w", err)
+	}
+	return f.WriteFile(job.filename, job.chain, job.method, job.test.Name)
 }
 
 type chainData struct {
-	bc     *core.BlockChain
-	gspec  *core.Genesis
-	blocks []*types.Block
-	// bad    *types.Block
+	name    string
+	bc      *core.BlockChain
+	gspec   *core.Genesis
+	blocks  []*types.Block
+	bad     *types.Block // invalid child of the head, if any
+	badFile string       // absolute path of the file holding the bad block
 }
 
-// initChain either attempts to read the chain config from args.ChainDir or it
-// generates a fresh test chain.
-func initChain(ctx context.Context, args *Args) (*chainData, error) {
-	var chain chainData
+// initChain either attempts to read the named chain from its directory in
+// args.ChainDir or it generates the chain and writes it to its directory in
+// the output directory.
+func initChain(ctx context.Context, args *Args, name string) (*chainData, error) {
+	chain := chainData{name: name}
 	if args.ChainDir != "" {
+		dir := fmt.Sprintf("%s/%s", args.ChainDir, name)
+		if isFlatChainDir(args.ChainDir) {
+			dir = args.ChainDir
+		}
 		chain.gspec = &core.Genesis{}
-		if g, err := os.ReadFile(fmt.Sprintf("%s/genesis.json", args.ChainDir)); err != nil {
-			return nil, err
+		if g, err := os.ReadFile(fmt.Sprintf("%s/genesis.json", dir)); err != nil {
+			return nil, fmt.Errorf("unable to read chain %s, expected %s/genesis.json or %s/%s/genesis.json: %w", name, args.ChainDir, args.ChainDir, name, err)
 		} else if err := json.Unmarshal(g, chain.gspec); err != nil {
 			return nil, err
 		}
-		b, err := readChain(fmt.Sprintf("%s/chain.rlp", args.ChainDir))
+		b, err := readChain(fmt.Sprintf("%s/chain.rlp", dir))
 		if err != nil {
 			return nil, err
 		}
 		chain.blocks = b
+		bad, err := readChain(fmt.Sprintf("%s/bad.rlp", dir))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if len(bad) > 0 {
+			chain.bad = bad[0]
+			chain.badFile = fmt.Sprintf("%s/bad.rlp", dir)
+		}
 	} else {
-		// Make consensus engine.
-		engine := beacon.NewFaker()
-
-		// Generate test chain and write to output directory.
-		var bad *types.Block
-		chain.gspec, chain.blocks, bad = genSimpleChain(engine)
-		if err := mkdir(args.OutDir); err != nil {
+		// Generate test chain and write to its output directory.
+		var err error
+		chain.gspec, chain.blocks, chain.bad, err = generateChain(args, name)
+		if err != nil {
+			return nil, err
+		}
+		dir := fmt.Sprintf("%s/%s", args.OutDir, name)
+		if err := mkdir(dir); err != nil {
 			return nil, err
 		}
-		if err := writeGenesis(fmt.Sprintf("%s/genesis.json", args.OutDir), chain.gspec); err != nil {
+		if err := writeGenesis(fmt.Sprintf("%s/genesis.json", dir), chain.gspec); err != nil {
 			return nil, err
 		}
-		if err := writeChain(fmt.Sprintf("%s/chain.rlp", args.OutDir), chain.blocks); err != nil {
+		if err := writeChain(fmt.Sprintf("%s/chain.rlp", dir), chain.blocks); err != nil {
 			return nil, err
 		}
-		if err := writeChain(fmt.Sprintf("%s/bad.rlp", args.OutDir), []*types.Block{bad}); err != nil {
+		if chain.bad != nil {
+			chain.badFile = fmt.Sprintf("%s/bad.rlp", dir)
+			if err := writeChain(chain.badFile, []*types.Block{chain.bad}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if chain.badFile != "" {
+		// Clients import the bad block from the file, whatever their working
+		// directory is.
+		abs, err := filepath.Abs(chain.badFile)
+		if err != nil {
 			return nil, err
 		}
+		chain.badFile = abs
 	}
 
 	// Create BlockChain to verify client responses against.
@@ -141,6 +435,54 @@ func initChain(ctx context.Context, args *Args) (*chainData, error) {
 	return &chain, nil
 }
 
+// isFlatChainDir reports whether the chain directory holds the files of a
+// single chain itself, rather than a subdirectory for each chain.
+func isFlatChainDir(dir string) bool {
+	_, err := os.Stat(fmt.Sprintf("%s/genesis.json", dir))
+	return err == nil
+}
+
+// generateChain generates the named chain. Chain definitions take precedence
+// over the built-in chains.
+func generateChain(args *Args, name string) (*core.Genesis, []*types.Block, *types.Block, error) {
+	if def, ok := args.chainDefs[name]; ok {
+		gspec, blocks, err := def.Generate(beacon.NewFaker())
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("unable to generate chain %s: %w", name, err)
+		}
+		return gspec, blocks, nil, nil
+	}
+	switch name {
+	case testgen.DefaultChain:
+		gspec, blocks, bad := genSimpleChain(beacon.NewFaker())
+		return gspec, blocks, bad, nil
+	case testgen.PreMergeChain:
+		engine := preMergeEngine(args)
+		defer engine.Close()
+		gspec, blocks := genPreMergeChain(engine, new(big.Int).SetUint64(args.Difficulty))
+		return gspec, blocks, nil, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown chain: %s", name)
+	}
+}
+
+// preMergeEngine returns the consensus engine the pre-merge chain is generated
+// with. Unless ethash sealing is enabled, the blocks have a fake proof-of-work.
+func preMergeEngine(args *Args) consensus.Engine {
+	if !args.Ethash {
+		return ethash.NewFaker()
+	}
+	return &sealingEngine{ethash.New(ethash.Config{
+		PowMode:        ethash.ModeNormal,
+		CacheDir:       args.EthashDir,
+		CachesInMem:    2,
+		CachesOnDisk:   3,
+		DatasetDir:     args.EthashDir,
+		DatasetsInMem:  1,
+		DatasetsOnDisk: 2,
+	}, nil, false), args.Verbose}
+}
+
 // spawnClient starts an Ethereum client on a separate thread.
 //
 // It waits until the client is responding to JSON-RPC requests
@@ -152,27 +494,104 @@ func spawnClient(ctx context.Context, args *Args, chain *chainData) (Client, err
 	)
 
 	// Initialize specified client and start it in a separate thread.
+	path := args.ClientBin
+	if path == "" {
+		path = args.ClientType
+	}
 	switch args.ClientType {
 	case "geth":
-		client, err = newGethClient(ctx, args.ClientBin, chain.gspec, chain.blocks, args.Verbose)
-		if err != nil {
-			return nil, err
+		client, err = newGethClient(ctx, path, chain, args.Verbose)
+	case "besu":
+		client, err = newBesuClient(ctx, path, chain, args.Verbose)
+	case "erigon":
+		client, err = newErigonClient(ctx, path, chain, args.Verbose)
+	case "nethermind":
+		client, err = newNethermindClient(ctx, path, chain, args.Verbose)
+	case "reth":
+		client, err = newRethClient(ctx, path, chain, args.Verbose)
+	case "external":
+		// The external client must serve the chain the tests are verified
+		// against, so it can't be generated on the fly.
+		if args.ChainDir == "" {
+			return nil, fmt.Errorf("external client requires --chain")
 		}
-		client.Start(ctx, args.Verbose)
+		client, err = newExternalClient(args.RPCAddr, args.WSAddr, args.AuthAddr, args.IPCPath)
 	default:
 		return nil, fmt.Errorf("unsupported client: %s", args.ClientType)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if rpcEndpoint(args, client) == "" {
+		client.Close()
+		return nil, fmt.Errorf("%s client does not serve JSON-RPC over %s", args.ClientType, args.Transport)
+	}
+	if err := client.Start(ctx, args.Verbose); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := waitForClient(ctx, rpcEndpoint(args, client), chain); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// rpcEndpoint returns the address of the client's JSON-RPC on the transport
+// the tests are filled over.
+func rpcEndpoint(args *Args, client Client) string {
+	if args.Transport == transportIPC {
+		return client.IPCPath()
+	}
+	return client.HttpAddr()
+}
 
-	// Try to connect for 5 seconds. Error otherwise.
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+// waitForClient waits until the client serves the chain at the JSON-RPC
+// endpoint.
+func waitForClient(ctx context.Context, addr string, chain *chainData) error {
+	// Try to connect for 30 seconds. Error otherwise. Some clients import
+	// the chain on startup, so wait until the head block is available.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	err = tryConnection(ctx, fmt.Sprintf("http://%s:%s", HOST, PORT), 500*time.Millisecond)
+	return tryConnection(ctx, addr, uint64(len(chain.blocks)), 500*time.Millisecond)
+}
+
+// clientVersion queries the client's version string.
+func clientVersion(ctx context.Context, addr string) (string, error) {
+	c, err := rpc.DialOptions(ctx, addr)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	defer c.Close()
+	var version string
+	if err := c.CallContext(ctx, &version, "web3_clientVersion"); err != nil {
+		return "", fmt.Errorf("unable to get client version: %w", err)
 	}
+	return version, nil
+}
 
-	return client, nil
+// generatorVersion returns the VCS revision rpctestgen was built from.
+func generatorVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				modified = "-dirty"
+			}
+		}
+	}
+	if revision == "" {
+		return "unknown"
+	}
+	return revision + modified
 }
 
 // mkdir makes a directory at the specified path, if it doesn't already exist.
@@ -185,16 +604,16 @@ func mkdir(path string) error {
 	return nil
 }
 
-// tryConnection checks if a client's JSON-RPC API is accepting requests.
-func tryConnection(ctx context.Context, addr string, waitTime time.Duration) error {
-	c, err := rpc.DialOptions(ctx, addr)
-	if err != nil {
-		return err
-	}
-	e := ethclient.NewClient(c)
+// tryConnection checks if a client's JSON-RPC API is accepting requests and
+// has reached the expected head block.
+func tryConnection(ctx context.Context, addr string, head uint64, waitTime time.Duration) error {
 	for {
-		if _, err := e.BlockNumber(ctx); err == nil {
-			break
+		// Dial on every attempt, IPC dials fail until the socket exists.
+		n, err := blockNumber(ctx, addr)
+		if err == nil && n >= head {
+			return nil
+		} else if err == nil {
+			err = fmt.Errorf("client at block %d, want %d", n, head)
 		}
 		select {
 		case <-ctx.Done():
@@ -202,5 +621,14 @@ func tryConnection(ctx context.Context, addr string, waitTime time.Duration) err
 		case <-time.After(waitTime):
 		}
 	}
-	return nil
+}
+
+// blockNumber queries the client's head block number.
+func blockNumber(ctx context.Context, addr string) (uint64, error) {
+	c, err := rpc.DialOptions(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	return ethclient.NewClient(c).BlockNumber(ctx)
 }
@@ -2,30 +2,75 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"os"
 	"regexp"
 
 	"github.com/alexflint/go-arg"
+	"github.com/lightclient/rpctestgen/chaindef"
+	"github.com/lightclient/rpctestgen/fixture"
+	"github.com/lightclient/rpctestgen/jwt"
+	"github.com/lightclient/rpctestgen/report"
+	"github.com/lightclient/rpctestgen/testgen"
 )
 
 const (
 	HOST        string = "127.0.0.1"
 	PORT        string = "13375"
 	NETWORKPORT string = "13376"
+	WSPORT      string = "13377"
+	AUTHPORT    string = "13378"
+)
+
+// Transports the tests can be filled over.
+const (
+	transportHTTP = "http"
+	transportIPC  = "ipc"
 )
 
 type Args struct {
-	ClientType  string `arg:"--client" help:"client type" default:"geth"`
-	ClientBin   string `arg:"--bin" help:"path to client binary" default:"geth"`
-	OutDir      string `arg:"--out" help:"directory where test fixtures will be written" default:"tests"`
-	ChainDir    string `arg:"--chain" help:"path to directory with chain.rlp and genesis.json"`
-	Verbose     bool   `arg:"-v,--verbose" help:"verbosity level of rpctestgen"`
-	LogLevel    string `arg:"--loglevel" help:"log level of client" default:"info"`
-	TestsRegexp string `arg:"--tests" help:"regex of tests to fill" default:".*"`
+	ClientType  string   `arg:"--client" help:"client type (geth, besu, erigon, nethermind, reth, external)" default:"geth"`
+	ClientBin   string   `arg:"--bin" help:"path to client binary (defaults to the client type)"`
+	Diff        []string `arg:"--diff" help:"fill the tests against each client, given as type or type=binary, and report where their responses differ"`
+	RPCAddr     string   `arg:"--rpc" help:"JSON-RPC address of an already running client, used with --client=external"`
+	WSAddr      string   `arg:"--ws" help:"websocket JSON-RPC address of an already running client, used with --client=external"`
+	AuthAddr    string   `arg:"--authrpc" help:"engine API address of an already running client, used with --client=external"`
+	IPCPath     string   `arg:"--ipc" help:"IPC socket path of an already running client, used with --client=external"`
+	Transport   string   `arg:"--transport" help:"transport used to fill the tests (http, ipc)" default:"http"`
+	JWTSecret   string   `arg:"--jwtsecret" help:"path to the hex encoded JWT secret of the engine API (defaults to a random secret)"`
+	OutDir      string   `arg:"--out" help:"directory where test fixtures will be written" default:"tests"`
+	Format      string   `arg:"--format" help:"format of the test fixtures (io, json)" default:"io"`
+	ChainDir    string   `arg:"--chain" help:"path to directory with a subdirectory holding chain.rlp and genesis.json for each chain, or holding those of a single chain"`
+	ChainDefs   []string `arg:"--chaindef,separate" help:"path to a chain definition (JSON or YAML), replaces the built-in chain of the same name"`
+	Chains      []string `arg:"--chains" help:"names of the chains to fill tests for (defaults to all)"`
+	Ethash      bool     `arg:"--ethash" help:"seal the pre-merge chain with ethash instead of a fake proof-of-work"`
+	EthashDir   string   `arg:"--ethashdir" help:"directory to store the ethash DAG in between runs"`
+	Difficulty  uint64   `arg:"--difficulty" help:"genesis difficulty of the pre-merge chain" default:"131072"`
+	Verbose     bool     `arg:"-v,--verbose" help:"verbosity level of rpctestgen"`
+	LogLevel    string   `arg:"--loglevel" help:"log level of client" default:"info"`
+	TestsRegexp string   `arg:"--tests" help:"regex of tests to fill" default:".*"`
+	Parallel    int      `arg:"--parallel" help:"number of read-only tests to fill concurrently" default:"1"`
+	Report      string   `arg:"--report" help:"write a report of the filled tests (junit, json)"`
+	ReportFile  string   `arg:"--report-out" help:"path of the report (defaults to report.xml or report.json)"`
 
 	tests       *regexp.Regexp
 	logLevelInt int
+	chainDefs   map[string]*chaindef.Definition
+	jwtSecret   [32]byte
+}
+
+// chainSelected reports whether tests of the named chain should be filled.
+func (args *Args) chainSelected(name string) bool {
+	if len(args.Chains) == 0 {
+		return true
+	}
+	for _, c := range args.Chains {
+		if c == name {
+			return true
+		}
+	}
+	return false
 }
 
 type ArgsKey struct{}
@@ -45,6 +90,43 @@ func main() {
 	if args.tests, err = regexp.Compile(args.TestsRegexp); err != nil {
 		exit(err)
 	}
+	args.chainDefs = make(map[string]*chaindef.Definition)
+	for _, filename := range args.ChainDefs {
+		def, err := chaindef.Load(filename)
+		if err != nil {
+			exit(err)
+		}
+		if def.Name == testgen.DefaultChain || def.Name == testgen.PreMergeChain {
+			fmt.Fprintf(os.Stderr, "warning: chain definition %s replaces the built-in chain, tests relying on its contents may fail\n", def.Name)
+		}
+		args.chainDefs[def.Name] = def
+	}
+	if args.JWTSecret != "" {
+		if args.jwtSecret, err = jwt.ReadSecret(args.JWTSecret); err != nil {
+			exit(err)
+		}
+	} else if args.AuthAddr != "" {
+		exit(fmt.Errorf("--authrpc requires --jwtsecret"))
+	} else if _, err := rand.Read(args.jwtSecret[:]); err != nil {
+		exit(err)
+	}
+	if args.Transport != transportHTTP && args.Transport != transportIPC {
+		exit(fmt.Errorf("unknown transport: %s", args.Transport))
+	}
+	if err := fixture.CheckFormat(args.Format); err != nil {
+		exit(err)
+	}
+	if args.Parallel < 1 {
+		exit(fmt.Errorf("invalid --parallel value: %d", args.Parallel))
+	}
+	if args.Report != "" {
+		if err := report.CheckFormat(args.Report); err != nil {
+			exit(err)
+		}
+		if args.ReportFile == "" {
+			args.ReportFile = report.DefaultFile(args.Report)
+		}
+	}
 
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, ARGS, &args)
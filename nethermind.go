@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// nethermindClient is a wrapper around a Nethermind instance on a separate
+// thread.
+type nethermindClient struct {
+	*process
+}
+
+// newNethermindClient instantiates a new nethermindClient.
+//
+// Nethermind does not understand geth's genesis format, so the genesis is
+// converted to a chainspec in the client's temporary data directory. There is
+// no separate import step: the chain is imported by the hive plugin when the
+// client starts.
+func newNethermindClient(ctx context.Context, path string, chain *chainData, verbose bool) (*nethermindClient, error) {
+	p, err := newProcess(path, chain, ctx.Value(ARGS).(*Args).jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := json.MarshalIndent(toChainspec(chain.gspec), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(fmt.Sprintf("%s/chainspec.json", p.workdir), spec, 0644); err != nil {
+		return nil, err
+	}
+
+	// Snapshot the datadir, so the client can be reset. Nethermind imports
+	// the chain on startup, so it is imported again after a reset.
+	if err := p.takeSnapshot(); err != nil {
+		return nil, err
+	}
+	return &nethermindClient{p}, nil
+}
+
+// Start starts nethermind, but does not wait for the command to exit.
+func (n *nethermindClient) Start(ctx context.Context, verbose bool) error {
+	fmt.Println("starting client")
+	var (
+		args    = ctx.Value(ARGS).(*Args)
+		options = []string{
+			"--config=none",
+			fmt.Sprintf("--datadir=%s", n.workdir),
+			fmt.Sprintf("--log=%s", logLevelName(args.logLevelInt)),
+			fmt.Sprintf("--Init.ChainSpecPath=%s/chainspec.json", n.workdir),
+			"--Init.DiscoveryEnabled=false",
+			"--Pruning.Mode=None",
+			"--Hive.Enabled=true",
+			fmt.Sprintf("--Hive.ChainFile=%s/chain.rlp", n.workdir),
+			fmt.Sprintf("--Network.P2PPort=%s", NETWORKPORT),
+			fmt.Sprintf("--Network.DiscoveryPort=%s", NETWORKPORT),
+			"--JsonRpc.Enabled=true",
+			"--JsonRpc.EnabledModules=[Admin,Eth,Debug,Web3]",
+			fmt.Sprintf("--JsonRpc.Host=%s", HOST),
+			fmt.Sprintf("--JsonRpc.Port=%s", PORT),
+			"--Init.WebSocketsEnabled=true",
+			fmt.Sprintf("--JsonRpc.WebSocketsPort=%s", WSPORT),
+			fmt.Sprintf("--JsonRpc.EngineHost=%s", HOST),
+			fmt.Sprintf("--JsonRpc.EnginePort=%s", AUTHPORT),
+			fmt.Sprintf("--JsonRpc.JwtSecretFile=%s", n.jwtSecretFile()),
+			fmt.Sprintf("--JsonRpc.IpcUnixDomainSocketPath=%s", n.IPCPath()),
+		}
+	)
+	return n.start(ctx, verbose, options...)
+}
+
+// IPCPath returns the path of nethermind's IPC socket.
+func (n *nethermindClient) IPCPath() string {
+	return n.ipcFile()
+}
+
+// toChainspec converts a geth genesis into a Nethermind chainspec.
+func toChainspec(genesis *core.Genesis) map[string]interface{} {
+	var (
+		config = genesis.Config
+		p      = map[string]interface{}{
+			"chainID":              hexutil.EncodeBig(config.ChainID),
+			"networkID":            hexutil.EncodeBig(config.ChainID),
+			"gasLimitBoundDivisor": "0x400",
+			"accountStartNonce":    "0x0",
+			"maximumExtraDataSize": "0x20",
+			"minGasLimit":          "0x1388",
+			"maxCodeSize":          "0x6000",
+		}
+		ethash = map[string]interface{}{
+			"minimumDifficulty":      "0x20000",
+			"difficultyBoundDivisor": "0x800",
+			"durationLimit":          "0xd",
+		}
+	)
+
+	// Map each geth fork onto the EIPs it activates.
+	setBlock(p, config.EIP150Block, "eip150Transition")
+	setBlock(p, config.EIP155Block, "eip155Transition")
+	setBlock(p, config.EIP158Block, "eip158Transition", "eip160Transition", "eip161abcTransition", "eip161dTransition", "maxCodeSizeTransition")
+	setBlock(p, config.ByzantiumBlock, "eip140Transition", "eip211Transition", "eip214Transition", "eip658Transition")
+	setBlock(p, config.ConstantinopleBlock, "eip145Transition", "eip1014Transition", "eip1052Transition", "eip1283Transition")
+	setBlock(p, config.PetersburgBlock, "eip1283DisableTransition")
+	setBlock(p, config.IstanbulBlock, "eip152Transition", "eip1108Transition", "eip1344Transition", "eip1884Transition", "eip2028Transition", "eip2200Transition")
+	setBlock(p, config.BerlinBlock, "eip2565Transition", "eip2929Transition", "eip2930Transition")
+	setBlock(p, config.LondonBlock, "eip1559Transition", "eip3198Transition", "eip3529Transition", "eip3541Transition")
+	setTime(p, config.ShanghaiTime, "eip3651TransitionTimestamp", "eip3855TransitionTimestamp", "eip3860TransitionTimestamp", "eip4895TransitionTimestamp")
+	if config.TerminalTotalDifficulty != nil {
+		p["terminalTotalDifficulty"] = hexutil.EncodeBig(config.TerminalTotalDifficulty)
+	}
+
+	// Configure the ethash engine, which is used until the merge.
+	setBlock(ethash, config.HomesteadBlock, "homesteadTransition")
+	setBlock(ethash, config.ByzantiumBlock, "eip100bTransition")
+	var (
+		rewards = make(map[string]interface{})
+		delays  = make(map[string]*big.Int)
+	)
+	for _, r := range []struct {
+		block  *big.Int
+		reward *big.Int
+	}{
+		{common.Big0, ethashReward(5)},
+		{config.ByzantiumBlock, ethashReward(3)},
+		{config.ConstantinopleBlock, ethashReward(2)},
+	} {
+		if r.block != nil {
+			rewards[hexutil.EncodeBig(r.block)] = hexutil.EncodeBig(r.reward)
+		}
+	}
+	for _, d := range []struct {
+		block *big.Int
+		delay int64
+	}{
+		{config.ByzantiumBlock, 3_000_000},
+		{config.ConstantinopleBlock, 2_000_000},
+		{config.MuirGlacierBlock, 4_000_000},
+		{config.LondonBlock, 700_000},
+		{config.ArrowGlacierBlock, 1_000_000},
+		{config.GrayGlacierBlock, 700_000},
+	} {
+		if d.block == nil {
+			continue
+		}
+		// Delays are cumulative, so forks scheduled at the same block add up.
+		key := hexutil.EncodeBig(d.block)
+		if delays[key] == nil {
+			delays[key] = new(big.Int)
+		}
+		delays[key].Add(delays[key], big.NewInt(d.delay))
+	}
+	ethash["blockReward"] = rewards
+	bombs := make(map[string]interface{})
+	for k, v := range delays {
+		bombs[k] = hexutil.EncodeBig(v)
+	}
+	ethash["difficultyBombDelays"] = bombs
+
+	// Convert the genesis header and allocation.
+	header := map[string]interface{}{
+		"seal": map[string]interface{}{
+			"ethereum": map[string]interface{}{
+				"nonce":   types.EncodeNonce(genesis.Nonce),
+				"mixHash": genesis.Mixhash,
+			},
+		},
+		"difficulty": hexutil.EncodeBig(genesis.Difficulty),
+		"author":     genesis.Coinbase,
+		"timestamp":  hexutil.Uint64(genesis.Timestamp),
+		"parentHash": genesis.ParentHash,
+		"extraData":  hexutil.Bytes(genesis.ExtraData),
+		"gasLimit":   hexutil.Uint64(genesis.GasLimit),
+	}
+	if genesis.BaseFee != nil {
+		header["baseFeePerGas"] = hexutil.EncodeBig(genesis.BaseFee)
+	}
+	accounts := make(map[string]interface{})
+	for addr, account := range genesis.Alloc {
+		acc := map[string]interface{}{
+			"balance": hexutil.EncodeBig(account.Balance),
+			"nonce":   hexutil.Uint64(account.Nonce),
+		}
+		if len(account.Code) != 0 {
+			acc["code"] = hexutil.Bytes(account.Code)
+		}
+		if len(account.Storage) != 0 {
+			acc["storage"] = account.Storage
+		}
+		accounts[strings.ToLower(addr.Hex())] = acc
+	}
+
+	return map[string]interface{}{
+		"name":     "rpctestgen",
+		"engine":   map[string]interface{}{"Ethash": map[string]interface{}{"params": ethash}},
+		"params":   p,
+		"genesis":  header,
+		"accounts": accounts,
+	}
+}
+
+// setBlock sets each key to the hex encoded block number, if the fork is
+// scheduled.
+func setBlock(m map[string]interface{}, block *big.Int, keys ...string) {
+	if block == nil {
+		return
+	}
+	for _, k := range keys {
+		m[k] = hexutil.EncodeBig(block)
+	}
+}
+
+// setTime sets each key to the hex encoded timestamp, if the fork is
+// scheduled.
+func setTime(m map[string]interface{}, time *uint64, keys ...string) {
+	if time == nil {
+		return
+	}
+	for _, k := range keys {
+		m[k] = hexutil.Uint64(*time)
+	}
+}
+
+// ethashReward returns the block reward in wei for the given amount of ether.
+func ethashReward(ether int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(ether), big.NewInt(params.Ether))
+}
@@ -0,0 +1,246 @@
+// Package report writes machine-readable summaries of test runs.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Supported report formats.
+const (
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// Test statuses.
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Result is the outcome of a single test.
+type Result struct {
+	Method   string
+	Name     string
+	About    string
+	Status   string
+	Duration time.Duration
+	Error    string
+}
+
+// Report collects the results of a test run.
+type Report struct {
+	Name    string
+	Results []*Result
+}
+
+// New creates an empty report for the named tool.
+func New(name string) *Report {
+	return &Report{Name: name}
+}
+
+// Add records the outcome of a test. A nil err marks the test as passed.
+func (r *Report) Add(method, name, about string, duration time.Duration, err error) {
+	res := &Result{
+		Method:   method,
+		Name:     name,
+		About:    about,
+		Status:   StatusPassed,
+		Duration: duration,
+	}
+	if err != nil {
+		res.Status = StatusFailed
+		res.Error = err.Error()
+	}
+	r.Results = append(r.Results, res)
+}
+
+// Skip records a test that wasn't run and the reason why.
+func (r *Report) Skip(method, name, about, reason string) {
+	r.Results = append(r.Results, &Result{
+		Method: method,
+		Name:   name,
+		About:  about,
+		Status: StatusSkipped,
+		Error:  reason,
+	})
+}
+
+// CheckFormat returns an error if the report format is not supported.
+func CheckFormat(format string) error {
+	switch format {
+	case FormatJSON, FormatJUnit:
+		return nil
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+// DefaultFile returns the default file name of a report in the format.
+func DefaultFile(format string) string {
+	if format == FormatJUnit {
+		return "report.xml"
+	}
+	return "report.json"
+}
+
+// WriteFile writes the report in the format to the file.
+func (r *Report) WriteFile(filename, format string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes the report in the format to w.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		return r.writeJSON(w)
+	case FormatJUnit:
+		return r.writeJUnit(w)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+type jsonResult struct {
+	Method   string  `json:"method"`
+	Name     string  `json:"name"`
+	About    string  `json:"about,omitempty"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"` // seconds
+	Error    string  `json:"error,omitempty"`
+}
+
+func (r *Report) writeJSON(w io.Writer) error {
+	out := struct {
+		Name    string        `json:"name"`
+		Results []*jsonResult `json:"results"`
+	}{Name: r.Name, Results: make([]*jsonResult, 0, len(r.Results))}
+	for _, res := range r.Results {
+		out.Results = append(out.Results, &jsonResult{
+			Method:   res.Method,
+			Name:     res.Name,
+			About:    res.About,
+			Status:   res.Status,
+			Duration: res.Duration.Seconds(),
+			Error:    res.Error,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+type junitSuites struct {
+	XMLName xml.Name      `xml:"testsuites"`
+	Name    string        `xml:"name,attr"`
+	Tests   int           `xml:"tests,attr"`
+	Failed  int           `xml:"failures,attr"`
+	Skipped int           `xml:"skipped,attr"`
+	Time    string        `xml:"time,attr"`
+	Suites  []*junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name    string       `xml:"name,attr"`
+	Tests   int          `xml:"tests,attr"`
+	Failed  int          `xml:"failures,attr"`
+	Skipped int          `xml:"skipped,attr"`
+	Time    string       `xml:"time,attr"`
+	Cases   []*junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name       string           `xml:"name,attr"`
+	Classname  string           `xml:"classname,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties []*junitProperty `xml:"properties>property,omitempty"`
+	Failure    *junitFailure    `xml:"failure,omitempty"`
+	Skipped    *junitSkipped    `xml:"skipped,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit writes the report with a test suite per method.
+func (r *Report) writeJUnit(w io.Writer) error {
+	var (
+		out    = &junitSuites{Name: r.Name}
+		suites = make(map[string]*junitSuite)
+		times  = make(map[*junitSuite]time.Duration)
+		total  time.Duration
+	)
+	for _, res := range r.Results {
+		suite, ok := suites[res.Method]
+		if !ok {
+			suite = &junitSuite{Name: res.Method}
+			suites[res.Method] = suite
+			out.Suites = append(out.Suites, suite)
+		}
+		c := &junitCase{
+			Name:      res.Name,
+			Classname: res.Method,
+			Time:      seconds(res.Duration),
+		}
+		if res.About != "" {
+			c.Properties = []*junitProperty{{Name: "about", Value: res.About}}
+		}
+		switch res.Status {
+		case StatusFailed:
+			c.Failure = &junitFailure{Message: res.Error, Text: res.Error}
+			suite.Failed++
+			out.Failed++
+		case StatusSkipped:
+			c.Skipped = &junitSkipped{Message: res.Error}
+			suite.Skipped++
+			out.Skipped++
+		}
+		suite.Cases = append(suite.Cases, c)
+		suite.Tests++
+		out.Tests++
+		times[suite] += res.Duration
+		total += res.Duration
+	}
+	for _, suite := range out.Suites {
+		suite.Time = seconds(times[suite])
+	}
+	out.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// rethClient is a wrapper around a reth instance on a separate thread.
+type rethClient struct {
+	*process
+}
+
+// newRethClient instantiates a new rethClient.
+//
+// The client's data directory is set to a temporary location and it
+// initializes with the genesis and the provided blocks.
+func newRethClient(ctx context.Context, path string, chain *chainData, verbose bool) (*rethClient, error) {
+	p, err := newProcess(path, chain, ctx.Value(ARGS).(*Args).jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+	r := &rethClient{p}
+
+	// Run reth init.
+	options := append([]string{"init"}, r.baseOptions(ctx)...)
+	if err := runCmd(ctx, path, verbose, options...); err != nil {
+		return nil, err
+	}
+
+	// Run reth import.
+	options = append([]string{"import"}, r.baseOptions(ctx)...)
+	options = append(options, fmt.Sprintf("%s/chain.rlp", p.workdir))
+	if err := runCmd(ctx, path, verbose, options...); err != nil {
+		return nil, err
+	}
+
+	// Snapshot the datadir, so the client can be reset.
+	if err := p.takeSnapshot(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Start starts reth, but does not wait for the command to exit.
+func (r *rethClient) Start(ctx context.Context, verbose bool) error {
+	fmt.Println("starting client")
+	options := append([]string{"node"}, r.baseOptions(ctx)...)
+	options = append(options,
+		fmt.Sprintf("--port=%s", NETWORKPORT),
+		"--disable-discovery",
+		"--http",
+		"--http.api=admin,eth,debug,web3",
+		fmt.Sprintf("--http.addr=%s", HOST),
+		fmt.Sprintf("--http.port=%s", PORT),
+		"--ws",
+		"--ws.api=admin,eth,debug,web3",
+		fmt.Sprintf("--ws.addr=%s", HOST),
+		fmt.Sprintf("--ws.port=%s", WSPORT),
+		fmt.Sprintf("--authrpc.addr=%s", HOST),
+		fmt.Sprintf("--authrpc.port=%s", AUTHPORT),
+		fmt.Sprintf("--authrpc.jwtsecret=%s", r.jwtSecretFile()),
+		fmt.Sprintf("--ipcpath=%s", r.IPCPath()),
+	)
+	return r.start(ctx, verbose, options...)
+}
+
+// IPCPath returns the path of reth's IPC socket.
+func (r *rethClient) IPCPath() string {
+	return r.ipcFile()
+}
+
+// baseOptions returns the options shared by every reth invocation.
+func (r *rethClient) baseOptions(ctx context.Context) []string {
+	args := ctx.Value(ARGS).(*Args)
+	return []string{
+		fmt.Sprintf("--datadir=%s", r.workdir),
+		fmt.Sprintf("--chain=%s/genesis.json", r.workdir),
+		// reth expresses its verbosity as -v through -vvvvv.
+		"-" + strings.Repeat("v", args.logLevelInt),
+	}
+}
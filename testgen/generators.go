@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
-	"strings"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/beacon/engine"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
@@ -18,7 +20,10 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/ethclient/gethclient"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/lightclient/rpctestgen/fixture"
+	"golang.org/x/exp/slices"
 )
 
 var (
@@ -26,26 +31,81 @@ var (
 	pk   *ecdsa.PrivateKey
 )
 
+// ErrSkip is returned by tests the client can't run, e.g. because it doesn't
+// serve an endpoint the test requires. Such tests are skipped instead of
+// failed and no fixture is written for them.
+var ErrSkip = errors.New("test skipped")
+
 func init() {
 	pk, _ = crypto.HexToECDSA("9c647b8b7c4e7c3490668fb6c11473619db80c93704c70893d3813af4090c39c")
 	addr = crypto.PubkeyToAddress(pk.PublicKey) // 658bdf435d810c91414ec09147daa6db62406379
 }
 
 type T struct {
-	eth   *ethclient.Client
-	geth  *gethclient.Client
-	rpc   *rpc.Client
-	chain *core.BlockChain
+	eth     *ethclient.Client
+	geth    *gethclient.Client
+	rpc     *rpc.Client
+	ws      *rpc.Client
+	engine  *rpc.Client // authenticated engine API, nil if not served
+	send    SendFunc
+	chain   *core.BlockChain
+	bad     *types.Block // invalid child of the head, nil if the chain has none
+	badFile string       // absolute path of the file holding the bad block, empty if the client can't read it
+}
+
+// SendFunc sends a raw JSON-RPC message over the client's regular transport
+// and returns the raw response, for tests of messages the rpc client can't
+// send or decode.
+type SendFunc func(ctx context.Context, msg json.RawMessage) (json.RawMessage, error)
+
+func NewT(eth *ethclient.Client, geth *gethclient.Client, rpc *rpc.Client, ws *rpc.Client, engine *rpc.Client, send SendFunc, chain *core.BlockChain, bad *types.Block, badFile string) *T {
+	return &T{eth, geth, rpc, ws, engine, send, chain, bad, badFile}
 }
 
-func NewT(eth *ethclient.Client, geth *gethclient.Client, rpc *rpc.Client, chain *core.BlockChain) *T {
-	return &T{eth, geth, rpc, chain}
+// subscribe opens a subscription in the "eth" namespace over the client's
+// websocket transport.
+func (t *T) subscribe(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error) {
+	if t.ws == nil {
+		return nil, fmt.Errorf("client does not serve websocket")
+	}
+	return t.ws.EthSubscribe(ctx, channel, args...)
 }
 
+// engineCall calls a method of the engine API over the client's authenticated
+// transport.
+func (t *T) engineCall(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if t.engine == nil {
+		return fmt.Errorf("%w: client does not serve the engine API", ErrSkip)
+	}
+	return t.engine.CallContext(ctx, result, method, args...)
+}
+
+// DefaultChain is the chain tests are filled against unless they name
+// another one.
+const DefaultChain = "simple"
+
+// PreMergeChain is a proof-of-work chain with uncles.
+const PreMergeChain = "premerge"
+
 // MethodTests is a collection of tests for a certain JSON-RPC method.
 type MethodTests struct {
 	Name  string
 	Tests []Test
+
+	// Chain is the chain the tests require, if not the default one.
+	Chain string
+}
+
+// ChainOf returns the name of the chain the test requires.
+func (m *MethodTests) ChainOf(test *Test) string {
+	switch {
+	case test.Chain != "":
+		return test.Chain
+	case m.Chain != "":
+		return m.Chain
+	default:
+		return DefaultChain
+	}
 }
 
 // Test is a wrapper for a function that performs an interaction with the
@@ -54,6 +114,101 @@ type Test struct {
 	Name  string
 	About string
 	Run   func(context.Context, *T) error
+
+	// ExpectError marks a negative test. Run must return the error of the
+	// call under test, which is then checked against the expectation.
+	ExpectError *fixture.ExpectedError
+
+	// Chain is the chain the test requires, if it differs from the chain
+	// of the other tests of the method.
+	Chain string
+
+	// Mutates marks tests that change the state of the client, e.g. by
+	// sending transactions. They are never filled concurrently with other
+	// tests and the client is reset after each of them.
+	Mutates bool
+
+	// Normalize lists the values of the responses which legitimately vary
+	// between clients or runs. The rules are recorded in the fixture, so
+	// that they are only checked loosely when the fixture is replayed.
+	Normalize []fixture.Rule
+}
+
+// Ignore allows the values at the path to differ or to be missing.
+func Ignore(path string) fixture.Rule {
+	return fixture.Rule{Kind: fixture.RuleIgnore, Path: path}
+}
+
+// SchemaOnly requires the values at the path to match the schema of the
+// method only.
+func SchemaOnly(path string) fixture.Rule {
+	return fixture.Rule{Kind: fixture.RuleSchema, Path: path}
+}
+
+// AnyQuantity allows the values at the path to be any quantity.
+func AnyQuantity(path string) fixture.Rule {
+	return fixture.Rule{Kind: fixture.RuleQuantity, Path: path}
+}
+
+// Standard JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Engine API error codes.
+const (
+	CodeUnknownPayload           = -38001
+	CodeInvalidForkchoiceState   = -38002
+	CodeInvalidPayloadAttributes = -38003
+	CodeTooLargeRequest          = -38004
+)
+
+// Execute runs the test. If the test expects an error, the error returned by
+// Run is checked against it, unless the test was skipped.
+func (test *Test) Execute(ctx context.Context, t *T) error {
+	err := test.Run(ctx, t)
+	if test.ExpectError == nil || errors.Is(err, ErrSkip) {
+		return err
+	}
+	return checkError(test.ExpectError, err)
+}
+
+// checkError checks the error returned by a negative test against the
+// expected one.
+func checkError(e *fixture.ExpectedError, err error) error {
+	if err == nil {
+		return fmt.Errorf("expected error, got none")
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return fmt.Errorf("expected json-rpc error, got: %w", err)
+	}
+	if e.Code != 0 && rpcErr.ErrorCode() != e.Code {
+		return fmt.Errorf("unexpected error code (got: %d, want: %d): %w", rpcErr.ErrorCode(), e.Code, err)
+	}
+	if e.Data == nil {
+		return nil
+	}
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return fmt.Errorf("expected error data, got none: %w", err)
+	}
+	got, err := json.Marshal(dataErr.ErrorData())
+	if err != nil {
+		return err
+	}
+	want, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("unexpected error data (got: %s, want: %s)", got, want)
+	}
+	return nil
 }
 
 // AllMethods is a slice of all JSON-RPC methods with tests.
@@ -83,21 +238,39 @@ var AllMethods = []MethodTests{
 	EthMaxPriorityFeePerGas,
 	EthSyncing,
 	EthFeeHistory,
-	// EthGetUncleByBlockNumberAndIndex,
+	Web3ClientVersion,
+	EthSubscribe,
+	EthGetLogs,
+	EthNewFilter,
+	EthGetFilterLogs,
+	EthGetFilterChanges,
+	EthGetUncleByBlockNumberAndIndex,
+	EthGetUncleByBlockHashAndIndex,
+	EthGetUncleCountByBlockNumber,
+	EthGetUncleCountByBlockHash,
 	DebugGetRawHeader,
 	DebugGetRawBlock,
 	DebugGetRawReceipts,
 	DebugGetRawTransaction,
+	DebugGetBadBlocks,
+	AdminImportChain,
+	Batch,
+	EngineExchangeCapabilities,
+	EngineForkchoiceUpdatedV2,
+	EngineGetPayloadV2,
+	EngineNewPayloadV2,
+	EngineGetPayloadBodiesByHashV1,
+	EngineGetPayloadBodiesByRangeV1,
 }
 
 // EthBlockNumber stores a list of all tests against the method.
 var EthBlockNumber = MethodTests{
-	"eth_blockNumber",
-	[]Test{
+	Name: "eth_blockNumber",
+	Tests: []Test{
 		{
-			"simple-test",
-			"retrieves the client's current block number",
-			func(ctx context.Context, t *T) error {
+			Name:  "simple-test",
+			About: "retrieves the client's current block number",
+			Run: func(ctx context.Context, t *T) error {
 				got, err := t.eth.BlockNumber(ctx)
 				if err != nil {
 					return err
@@ -112,12 +285,12 @@ var EthBlockNumber = MethodTests{
 
 // EthChainID stores a list of all tests against the method.
 var EthChainID = MethodTests{
-	"eth_chainId",
-	[]Test{
+	Name: "eth_chainId",
+	Tests: []Test{
 		{
-			"get-chain-id",
-			"retrieves the client's current chain id",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-chain-id",
+			About: "retrieves the client's current chain id",
+			Run: func(ctx context.Context, t *T) error {
 				got, err := t.eth.ChainID(ctx)
 				if err != nil {
 					return err
@@ -132,12 +305,12 @@ var EthChainID = MethodTests{
 
 // EthGetHeaderByNumber stores a list of all tests against the method.
 var EthGetHeaderByNumber = MethodTests{
-	"eth_getHeaderByNumber",
-	[]Test{
+	Name: "eth_getHeaderByNumber",
+	Tests: []Test{
 		{
-			"get-header-by-number",
-			"gets a header by number",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-header-by-number",
+			About: "gets a header by number",
+			Run: func(ctx context.Context, t *T) error {
 				var got *types.Header
 				err := t.rpc.CallContext(ctx, got, "eth_getHeaderByNumber", "0x1")
 				if err != nil {
@@ -155,12 +328,12 @@ var EthGetHeaderByNumber = MethodTests{
 
 // EthGetHeaderByHash stores a list of all tests against the method.
 var EthGetHeaderByHash = MethodTests{
-	"eth_getHeaderByHash",
-	[]Test{
+	Name: "eth_getHeaderByHash",
+	Tests: []Test{
 		{
-			"get-header-by-hash",
-			"gets a header by hash",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-header-by-hash",
+			About: "gets a header by hash",
+			Run: func(ctx context.Context, t *T) error {
 				want := t.chain.GetHeaderByNumber(1)
 				var got *types.Header
 				err := t.rpc.CallContext(ctx, got, "eth_getHeaderByHash", want.Hash())
@@ -178,12 +351,12 @@ var EthGetHeaderByHash = MethodTests{
 
 // EthGetCode stores a list of all tests against the method.
 var EthGetCode = MethodTests{
-	"eth_getCode",
-	[]Test{
+	Name: "eth_getCode",
+	Tests: []Test{
 		{
-			"get-code",
-			"gets code for 0xaa",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-code",
+			About: "gets code for 0xaa",
+			Run: func(ctx context.Context, t *T) error {
 				addr := common.Address{0xaa}
 				var got hexutil.Bytes
 				err := t.rpc.CallContext(ctx, &got, "eth_getCode", addr, "latest")
@@ -203,12 +376,12 @@ var EthGetCode = MethodTests{
 
 // EthGetStorage stores a list of all tests against the method.
 var EthGetStorage = MethodTests{
-	"eth_getStorage",
-	[]Test{
+	Name: "eth_getStorage",
+	Tests: []Test{
 		{
-			"get-storage",
-			"gets storage for 0xaa",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-storage",
+			About: "gets storage for 0xaa",
+			Run: func(ctx context.Context, t *T) error {
 				addr := common.Address{0xaa}
 				key := common.Hash{0x01}
 				got, err := t.eth.StorageAt(ctx, addr, key, nil)
@@ -228,12 +401,12 @@ var EthGetStorage = MethodTests{
 
 // EthGetBlockByHash stores a list of all tests against the method.
 var EthGetBlockByHash = MethodTests{
-	"eth_getBlockByHash",
-	[]Test{
+	Name: "eth_getBlockByHash",
+	Tests: []Test{
 		{
-			"get-block-by-hash",
-			"gets block 1",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-block-by-hash",
+			About: "gets block 1",
+			Run: func(ctx context.Context, t *T) error {
 				want := t.chain.GetHeaderByNumber(1)
 				got, err := t.eth.BlockByHash(ctx, want.Hash())
 				if err != nil {
@@ -245,17 +418,30 @@ var EthGetBlockByHash = MethodTests{
 				return nil
 			},
 		},
+		{
+			Name:  "get-block-total-difficulty",
+			About: "gets a proof-of-work block and its total difficulty",
+			Chain: PreMergeChain,
+			Run: func(ctx context.Context, t *T) error {
+				var got *powBlock
+				hash := t.chain.GetHeaderByNumber(2).Hash()
+				if err := t.rpc.CallContext(ctx, &got, "eth_getBlockByHash", hash, false); err != nil {
+					return err
+				}
+				return checkPowBlock(t, 2, got)
+			},
+		},
 	},
 }
 
 // EthChainID stores a list of all tests against the method.
 var EthGetBalance = MethodTests{
-	"eth_getBalance",
-	[]Test{
+	Name: "eth_getBalance",
+	Tests: []Test{
 		{
-			"get-balance",
-			"retrieves the an account's balance",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-balance",
+			About: "retrieves the an account's balance",
+			Run: func(ctx context.Context, t *T) error {
 				addr := common.Address{0xaa}
 				got, err := t.eth.BalanceAt(ctx, addr, nil)
 				if err != nil {
@@ -270,9 +456,9 @@ var EthGetBalance = MethodTests{
 			},
 		},
 		{
-			"get-balance-blockhash",
-			"retrieves the an account's balance at a specific blockhash",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-balance-blockhash",
+			About: "retrieves the an account's balance at a specific blockhash",
+			Run: func(ctx context.Context, t *T) error {
 				var (
 					block = t.chain.GetBlockByNumber(1)
 					addr  = common.Address{0xaa}
@@ -294,12 +480,12 @@ var EthGetBalance = MethodTests{
 
 // EthGetBlockByNumber stores a list of all tests against the method.
 var EthGetBlockByNumber = MethodTests{
-	"eth_getBlockByNumber",
-	[]Test{
+	Name: "eth_getBlockByNumber",
+	Tests: []Test{
 		{
-			"get-genesis",
-			"gets block 0",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-genesis",
+			About: "gets block 0",
+			Run: func(ctx context.Context, t *T) error {
 				block, err := t.eth.BlockByNumber(ctx, common.Big0)
 				if err != nil {
 					return err
@@ -311,9 +497,9 @@ var EthGetBlockByNumber = MethodTests{
 			},
 		},
 		{
-			"get-block-n",
-			"gets block 2",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-block-n",
+			About: "gets block 2",
+			Run: func(ctx context.Context, t *T) error {
 				block, err := t.eth.BlockByNumber(ctx, common.Big2)
 				if err != nil {
 					return err
@@ -324,17 +510,29 @@ var EthGetBlockByNumber = MethodTests{
 				return nil
 			},
 		},
+		{
+			Name:  "get-block-with-uncles",
+			About: "gets a proof-of-work block with uncles and its total difficulty",
+			Chain: PreMergeChain,
+			Run: func(ctx context.Context, t *T) error {
+				var got *powBlock
+				if err := t.rpc.CallContext(ctx, &got, "eth_getBlockByNumber", hexutil.Uint(4), false); err != nil {
+					return err
+				}
+				return checkPowBlock(t, 4, got)
+			},
+		},
 	},
 }
 
 // EthCall stores a list of all tests against the method.
 var EthCall = MethodTests{
-	"eth_call",
-	[]Test{
+	Name: "eth_call",
+	Tests: []Test{
 		{
-			"call-simple-transfer",
-			"simulates a simple transfer",
-			func(ctx context.Context, t *T) error {
+			Name:  "call-simple-transfer",
+			About: "simulates a simple transfer",
+			Run: func(ctx context.Context, t *T) error {
 				msg := ethereum.CallMsg{From: common.Address{0xaa}, To: &common.Address{0x01}, Gas: 100000}
 				got, err := t.eth.CallContract(ctx, msg, nil)
 				if err != nil {
@@ -347,9 +545,9 @@ var EthCall = MethodTests{
 			},
 		},
 		{
-			"call-simple-contract",
-			"simulates a simple contract call with no return",
-			func(ctx context.Context, t *T) error {
+			Name:  "call-simple-contract",
+			About: "simulates a simple contract call with no return",
+			Run: func(ctx context.Context, t *T) error {
 				aa := common.Address{0xaa}
 				msg := ethereum.CallMsg{From: aa, To: &aa}
 				got, err := t.eth.CallContract(ctx, msg, nil)
@@ -367,12 +565,12 @@ var EthCall = MethodTests{
 
 // EthEstimateGas stores a list of all tests against the method.
 var EthEstimateGas = MethodTests{
-	"eth_estimateGas",
-	[]Test{
+	Name: "eth_estimateGas",
+	Tests: []Test{
 		{
-			"estimate-simple-transfer",
-			"estimates a simple transfer",
-			func(ctx context.Context, t *T) error {
+			Name:  "estimate-simple-transfer",
+			About: "estimates a simple transfer",
+			Run: func(ctx context.Context, t *T) error {
 				msg := ethereum.CallMsg{From: common.Address{0xaa}, To: &common.Address{0x01}}
 				got, err := t.eth.EstimateGas(ctx, msg)
 				if err != nil {
@@ -385,9 +583,9 @@ var EthEstimateGas = MethodTests{
 			},
 		},
 		{
-			"estimate-simple-contract",
-			"estimates a simple contract call with no return",
-			func(ctx context.Context, t *T) error {
+			Name:  "estimate-simple-contract",
+			About: "estimates a simple contract call with no return",
+			Run: func(ctx context.Context, t *T) error {
 				aa := common.Address{0xaa}
 				msg := ethereum.CallMsg{From: aa, To: &aa}
 				got, err := t.eth.EstimateGas(ctx, msg)
@@ -406,12 +604,12 @@ var EthEstimateGas = MethodTests{
 
 // EthEstimateGas stores a list of all tests against the method.
 var EthCreateAccessList = MethodTests{
-	"eth_createAccessList",
-	[]Test{
+	Name: "eth_createAccessList",
+	Tests: []Test{
 		{
-			"create-al-simple-transfer",
-			"estimates a simple transfer",
-			func(ctx context.Context, t *T) error {
+			Name:  "create-al-simple-transfer",
+			About: "estimates a simple transfer",
+			Run: func(ctx context.Context, t *T) error {
 				msg := make(map[string]interface{})
 				msg["from"] = addr
 				msg["to"] = common.Address{0x01}
@@ -425,9 +623,9 @@ var EthCreateAccessList = MethodTests{
 			},
 		},
 		{
-			"create-al-simple-contract",
-			"estimates a simple contract call with no return",
-			func(ctx context.Context, t *T) error {
+			Name:  "create-al-simple-contract",
+			About: "estimates a simple contract call with no return",
+			Run: func(ctx context.Context, t *T) error {
 				msg := make(map[string]interface{})
 				msg["from"] = addr
 				msg["to"] = common.Address{0xaa}
@@ -441,9 +639,9 @@ var EthCreateAccessList = MethodTests{
 			},
 		},
 		{
-			"create-al-multiple-reads",
-			"estimates a simple contract call with no return",
-			func(ctx context.Context, t *T) error {
+			Name:  "create-al-multiple-reads",
+			About: "estimates a simple contract call with no return",
+			Run: func(ctx context.Context, t *T) error {
 				msg := make(map[string]interface{})
 				msg["from"] = addr
 				msg["to"] = common.Address{0xbb}
@@ -461,12 +659,12 @@ var EthCreateAccessList = MethodTests{
 
 // EthGetBlockTransactionCountByNumber stores a list of all tests against the method.
 var EthGetBlockTransactionCountByNumber = MethodTests{
-	"eth_getBlockTransactionCountByNumber",
-	[]Test{
+	Name: "eth_getBlockTransactionCountByNumber",
+	Tests: []Test{
 		{
-			"get-genesis",
-			"gets tx count in block 0",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-genesis",
+			About: "gets tx count in block 0",
+			Run: func(ctx context.Context, t *T) error {
 				var got hexutil.Uint
 				err := t.rpc.CallContext(ctx, &got, "eth_getBlockTransactionCountByNumber", hexutil.Uint(0))
 				if err != nil {
@@ -480,9 +678,9 @@ var EthGetBlockTransactionCountByNumber = MethodTests{
 			},
 		},
 		{
-			"get-block-n",
-			"gets tx count in block 2",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-block-n",
+			About: "gets tx count in block 2",
+			Run: func(ctx context.Context, t *T) error {
 				var got hexutil.Uint
 				err := t.rpc.CallContext(ctx, &got, "eth_getBlockTransactionCountByNumber", hexutil.Uint(2))
 				if err != nil {
@@ -500,12 +698,12 @@ var EthGetBlockTransactionCountByNumber = MethodTests{
 
 // EthGetBlockTransactionCountByHash stores a list of all tests against the method.
 var EthGetBlockTransactionCountByHash = MethodTests{
-	"eth_getBlockTransactionCountByHash",
-	[]Test{
+	Name: "eth_getBlockTransactionCountByHash",
+	Tests: []Test{
 		{
-			"get-genesis",
-			"gets tx count in block 0",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-genesis",
+			About: "gets tx count in block 0",
+			Run: func(ctx context.Context, t *T) error {
 				block := t.chain.GetBlockByNumber(0)
 				var got hexutil.Uint
 				err := t.rpc.CallContext(ctx, &got, "eth_getBlockTransactionCountByHash", block.Hash())
@@ -520,9 +718,9 @@ var EthGetBlockTransactionCountByHash = MethodTests{
 			},
 		},
 		{
-			"get-block-n",
-			"gets tx count in block 2",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-block-n",
+			About: "gets tx count in block 2",
+			Run: func(ctx context.Context, t *T) error {
 				block := t.chain.GetBlockByNumber(2)
 				var got hexutil.Uint
 				err := t.rpc.CallContext(ctx, &got, "eth_getBlockTransactionCountByHash", block.Hash())
@@ -541,12 +739,12 @@ var EthGetBlockTransactionCountByHash = MethodTests{
 
 // EthGetTransactionByBlockHashAndIndex stores a list of all tests against the method.
 var EthGetTransactionByBlockHashAndIndex = MethodTests{
-	"eth_getTransactionByBlockNumberAndIndex",
-	[]Test{
+	Name: "eth_getTransactionByBlockNumberAndIndex",
+	Tests: []Test{
 		{
-			"get-block-n",
-			"gets tx 0 in block 2",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-block-n",
+			About: "gets tx 0 in block 2",
+			Run: func(ctx context.Context, t *T) error {
 				var got types.Transaction
 				err := t.rpc.CallContext(ctx, &got, "eth_getTransactionByBlockNumberAndIndex", hexutil.Uint(2), hexutil.Uint(0))
 				if err != nil {
@@ -564,12 +762,12 @@ var EthGetTransactionByBlockHashAndIndex = MethodTests{
 
 // EthGetTransactionByBlockNumberAndIndex stores a list of all tests against the method.
 var EthGetTransactionByBlockNumberAndIndex = MethodTests{
-	"eth_getTransactionByBlockHashAndIndex",
-	[]Test{
+	Name: "eth_getTransactionByBlockHashAndIndex",
+	Tests: []Test{
 		{
-			"get-block-n",
-			"gets tx 0 in block 2",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-block-n",
+			About: "gets tx 0 in block 2",
+			Run: func(ctx context.Context, t *T) error {
 				block := t.chain.GetBlockByNumber(2)
 				var got types.Transaction
 				err := t.rpc.CallContext(ctx, &got, "eth_getTransactionByBlockHashAndIndex", block.Hash(), hexutil.Uint(0))
@@ -588,12 +786,12 @@ var EthGetTransactionByBlockNumberAndIndex = MethodTests{
 
 // EthGetTransactionCount stores a list of all tests against the method.
 var EthGetTransactionCount = MethodTests{
-	"eth_getTransactionCount",
-	[]Test{
+	Name: "eth_getTransactionCount",
+	Tests: []Test{
 		{
-			"get-account-nonce",
-			"gets nonce for a certain account",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-account-nonce",
+			About: "gets nonce for a certain account",
+			Run: func(ctx context.Context, t *T) error {
 				addr := common.Address{0xaa}
 				got, err := t.eth.NonceAt(ctx, addr, nil)
 				if err != nil {
@@ -611,46 +809,92 @@ var EthGetTransactionCount = MethodTests{
 }
 
 // EthGetTransactionByHash stores a list of all tests against the method.
-// TODO: do legacy, al, and dynamic txs
 var EthGetTransactionByHash = MethodTests{
-	"eth_getTransactionByHash",
-	[]Test{
+	Name: "eth_getTransactionByHash",
+	Tests: []Test{
 		{
-			"get-legacy-tx",
-			"gets a legacy transaction",
-			func(ctx context.Context, t *T) error {
-				want := t.chain.GetBlockByNumber(2).Transactions()[0]
-				got, _, err := t.eth.TransactionByHash(ctx, want.Hash())
-				if err != nil {
-					return err
-				}
-				if got.Hash() != want.Hash() {
-					return fmt.Errorf("tx mismatch (got: %s, want: %s)", got.Hash(), want.Hash())
-				}
-				return nil
+			Name:  "get-legacy-tx",
+			About: "gets a legacy transaction",
+			Run: func(ctx context.Context, t *T) error {
+				return checkTransaction(ctx, t, 2, 0, types.LegacyTxType)
+			},
+		},
+		{
+			Name:  "get-access-list-tx",
+			About: "gets an access list transaction",
+			Run: func(ctx context.Context, t *T) error {
+				return checkTransaction(ctx, t, 4, 0, types.AccessListTxType)
+			},
+		},
+		{
+			Name:  "get-dynamic-fee-tx",
+			About: "gets a dynamic fee transaction",
+			Run: func(ctx context.Context, t *T) error {
+				return checkTransaction(ctx, t, 4, 1, types.DynamicFeeTxType)
+			},
+		},
+		{
+			Name:  "get-contract-creation-tx",
+			About: "gets a transaction creating a contract",
+			Run: func(ctx context.Context, t *T) error {
+				return checkTransaction(ctx, t, 5, 0, types.DynamicFeeTxType)
 			},
 		},
 	},
 }
 
 // EthGetTransactionReceipt stores a list of all tests against the method.
-// TODO: do legacy, al, and dynamic txs
 var EthGetTransactionReceipt = MethodTests{
-	"eth_getTransactionReceipt",
-	[]Test{
+	Name: "eth_getTransactionReceipt",
+	Tests: []Test{
 		{
-			"get-legacy-receipt",
-			"gets a receipt for a legacy transaction",
-			func(ctx context.Context, t *T) error {
-				block := t.chain.GetBlockByNumber(2)
-				receipt, err := t.eth.TransactionReceipt(ctx, block.Transactions()[0].Hash())
+			Name:  "get-legacy-receipt",
+			About: "gets a receipt for a legacy transaction",
+			Run: func(ctx context.Context, t *T) error {
+				_, err := checkReceipt(ctx, t, 2, 0, types.LegacyTxType)
+				return err
+			},
+		},
+		{
+			Name:  "get-access-list-receipt",
+			About: "gets a receipt for an access list transaction",
+			Run: func(ctx context.Context, t *T) error {
+				_, err := checkReceipt(ctx, t, 4, 0, types.AccessListTxType)
+				return err
+			},
+		},
+		{
+			Name:  "get-dynamic-fee-receipt",
+			About: "gets a receipt for a dynamic fee transaction",
+			Run: func(ctx context.Context, t *T) error {
+				_, err := checkReceipt(ctx, t, 4, 1, types.DynamicFeeTxType)
+				return err
+			},
+		},
+		{
+			Name:  "get-contract-creation-receipt",
+			About: "gets a receipt for a transaction creating a contract",
+			Run: func(ctx context.Context, t *T) error {
+				receipt, err := checkReceipt(ctx, t, 5, 0, types.DynamicFeeTxType)
 				if err != nil {
 					return err
 				}
-				got, _ := receipt.MarshalBinary()
-				want, _ := t.chain.GetReceiptsByHash(block.Hash())[0].MarshalBinary()
-				if !bytes.Equal(got, want) {
-					return fmt.Errorf("receipt mismatch (got: %s, want: %s)", hexutil.Bytes(got), hexutil.Bytes(want))
+				if receipt.ContractAddress == (common.Address{}) {
+					return fmt.Errorf("missing contract address")
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "get-reverted-receipt",
+			About: "gets a receipt for a transaction that reverted",
+			Run: func(ctx context.Context, t *T) error {
+				receipt, err := checkReceipt(ctx, t, 5, 1, types.LegacyTxType)
+				if err != nil {
+					return err
+				}
+				if receipt.Status != types.ReceiptStatusFailed {
+					return fmt.Errorf("unexpected receipt status (got: %d, want: %d)", receipt.Status, types.ReceiptStatusFailed)
 				}
 				return nil
 			},
@@ -659,14 +903,14 @@ var EthGetTransactionReceipt = MethodTests{
 }
 
 // EthSendRawTransaction stores a list of all tests against the method.
-// TODO: do legacy, al, and dynamic txs
 var EthSendRawTransaction = MethodTests{
-	"eth_sendRawTransaction",
-	[]Test{
+	Name: "eth_sendRawTransaction",
+	Tests: []Test{
 		{
-			"send-legacy-transaction",
-			"sends a raw legacy transaction",
-			func(ctx context.Context, t *T) error {
+			Name:    "send-legacy-transaction",
+			About:   "sends a raw legacy transaction",
+			Mutates: true,
+			Run: func(ctx context.Context, t *T) error {
 				genesis := t.chain.Genesis()
 				state, _ := t.chain.State()
 				txdata := &types.LegacyTx{
@@ -685,17 +929,70 @@ var EthSendRawTransaction = MethodTests{
 				return nil
 			},
 		},
+		{
+			Name:    "send-access-list-transaction",
+			About:   "sends a raw access list transaction",
+			Mutates: true,
+			Run: func(ctx context.Context, t *T) error {
+				genesis := t.chain.Genesis()
+				state, _ := t.chain.State()
+				txdata := &types.AccessListTx{
+					ChainID:  t.chain.Config().ChainID,
+					Nonce:    state.GetNonce(addr),
+					To:       &common.Address{0xbb},
+					Gas:      50000,
+					GasPrice: new(big.Int).Add(genesis.BaseFee(), big.NewInt(1)),
+					AccessList: types.AccessList{{
+						Address:     common.Address{0xbb},
+						StorageKeys: []common.Hash{{0x01}},
+					}},
+				}
+				s := types.MakeSigner(t.chain.Config(), t.chain.CurrentHeader().Number)
+				tx, _ := types.SignNewTx(pk, s, txdata)
+				if err := t.eth.SendTransaction(ctx, tx); err != nil {
+					return err
+				}
+				return nil
+			},
+		},
+		{
+			Name:    "send-dynamic-fee-transaction",
+			About:   "sends a raw dynamic fee transaction",
+			Mutates: true,
+			Run: func(ctx context.Context, t *T) error {
+				genesis := t.chain.Genesis()
+				state, _ := t.chain.State()
+				txdata := &types.DynamicFeeTx{
+					ChainID:   t.chain.Config().ChainID,
+					Nonce:     state.GetNonce(addr),
+					To:        &common.Address{0xaa},
+					Value:     big.NewInt(10),
+					Gas:       25000,
+					GasTipCap: big.NewInt(1),
+					GasFeeCap: new(big.Int).Add(genesis.BaseFee(), big.NewInt(1)),
+					Data:      common.FromHex("5544"),
+				}
+				s := types.MakeSigner(t.chain.Config(), t.chain.CurrentHeader().Number)
+				tx, _ := types.SignNewTx(pk, s, txdata)
+				if err := t.eth.SendTransaction(ctx, tx); err != nil {
+					return err
+				}
+				return nil
+			},
+		},
 	},
 }
 
 // EthGasPrice stores a list of all tests against the method.
 var EthGasPrice = MethodTests{
-	"eth_gasPrice",
-	[]Test{
+	Name: "eth_gasPrice",
+	Tests: []Test{
 		{
-			"get-current-gas-price",
-			"gets the current gas price in wei",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-current-gas-price",
+			About: "gets the current gas price in wei",
+			// The suggestion depends on the client's gas price oracle.
+			Normalize: []fixture.Rule{AnyQuantity("result")},
+			Run: func(ctx context.Context, t *T) error {
 				if _, err := t.eth.SuggestGasPrice(ctx); err != nil {
 					return err
 				}
@@ -707,12 +1004,14 @@ var EthGasPrice = MethodTests{
 
 // EthMaxPriorityFeePerGas stores a list of all tests against the method.
 var EthMaxPriorityFeePerGas = MethodTests{
-	"eth_maxPriorityFeePerGas",
-	[]Test{
+	Name: "eth_maxPriorityFeePerGas",
+	Tests: []Test{
 		{
-			"get-current-tip",
-			"gets the current maxPriorityFeePerGas in wei",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-current-tip",
+			About: "gets the current maxPriorityFeePerGas in wei",
+			// The suggestion depends on the client's gas price oracle.
+			Normalize: []fixture.Rule{AnyQuantity("result")},
+			Run: func(ctx context.Context, t *T) error {
 				if _, err := t.eth.SuggestGasTipCap(ctx); err != nil {
 					return err
 				}
@@ -724,12 +1023,12 @@ var EthMaxPriorityFeePerGas = MethodTests{
 
 // EthFeeHistory stores a list of all tests against the method.
 var EthFeeHistory = MethodTests{
-	"eth_feeHistory",
-	[]Test{
+	Name: "eth_feeHistory",
+	Tests: []Test{
 		{
-			"fee-history",
-			"gets fee history information",
-			func(ctx context.Context, t *T) error {
+			Name:  "fee-history",
+			About: "gets fee history information",
+			Run: func(ctx context.Context, t *T) error {
 				got, err := t.eth.FeeHistory(ctx, 1, big.NewInt(2), []float64{95, 99})
 				if err != nil {
 					return err
@@ -754,12 +1053,14 @@ var EthFeeHistory = MethodTests{
 
 // EthSyncing stores a list of all tests against the method.
 var EthSyncing = MethodTests{
-	"eth_syncing",
-	[]Test{
+	Name: "eth_syncing",
+	Tests: []Test{
 		{
-			"check-syncing",
-			"checks client syncing status",
-			func(ctx context.Context, t *T) error {
+			Name:  "check-syncing",
+			About: "checks client syncing status",
+			// Clients report the progress of their own sync stages.
+			Normalize: []fixture.Rule{SchemaOnly("result")},
+			Run: func(ctx context.Context, t *T) error {
 				_, err := t.eth.SyncProgress(ctx)
 				if err != nil {
 					return err
@@ -770,19 +1071,443 @@ var EthSyncing = MethodTests{
 	},
 }
 
+// Web3ClientVersion stores a list of all tests against the method.
+var Web3ClientVersion = MethodTests{
+	Name: "web3_clientVersion",
+	Tests: []Test{
+		{
+			Name:  "get-client-version",
+			About: "gets the client's version string",
+			// Every client identifies itself differently.
+			Normalize: []fixture.Rule{SchemaOnly("result")},
+			Run: func(ctx context.Context, t *T) error {
+				var version string
+				if err := t.rpc.CallContext(ctx, &version, "web3_clientVersion"); err != nil {
+					return err
+				}
+				if version == "" {
+					return fmt.Errorf("empty client version")
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// EthSubscribe stores a list of all tests against the method.
+var EthSubscribe = MethodTests{
+	Name: "eth_subscribe",
+	Tests: []Test{
+		{
+			Name:  "subscribe-new-heads",
+			About: "subscribes to new heads and unsubscribes",
+			// Subscription ids are random.
+			Normalize: []fixture.Rule{
+				SchemaOnly("result").For("eth_subscribe"),
+				SchemaOnly("params.subscription").For("eth_subscription"),
+			},
+			Run: func(ctx context.Context, t *T) error {
+				ch := make(chan *types.Header)
+				sub, err := t.subscribe(ctx, ch, "newHeads")
+				if err != nil {
+					return err
+				}
+				sub.Unsubscribe()
+				return nil
+			},
+		},
+		{
+			Name:  "subscribe-logs",
+			About: "subscribes to logs emitted by 0xaa and unsubscribes",
+			// Subscription ids are random.
+			Normalize: []fixture.Rule{
+				SchemaOnly("result").For("eth_subscribe"),
+				SchemaOnly("params.subscription").For("eth_subscription"),
+			},
+			Run: func(ctx context.Context, t *T) error {
+				ch := make(chan types.Log)
+				filter := map[string]interface{}{"address": common.Address{0xaa}}
+				sub, err := t.subscribe(ctx, ch, "logs", filter)
+				if err != nil {
+					return err
+				}
+				sub.Unsubscribe()
+				return nil
+			},
+		},
+		{
+			Name:    "subscribe-pending-transactions",
+			About:   "subscribes to pending transactions and receives the hash of a sent transaction",
+			Mutates: true,
+			// Subscription ids are random.
+			Normalize: []fixture.Rule{
+				SchemaOnly("result").For("eth_subscribe"),
+				SchemaOnly("params.subscription").For("eth_subscription"),
+			},
+			Run: func(ctx context.Context, t *T) error {
+				ch := make(chan common.Hash)
+				sub, err := t.subscribe(ctx, ch, "newPendingTransactions")
+				if err != nil {
+					return err
+				}
+				defer sub.Unsubscribe()
+
+				// Only executable transactions are announced, so use the
+				// pending nonce in case other tests already sent one.
+				nonce, err := t.eth.PendingNonceAt(ctx, addr)
+				if err != nil {
+					return err
+				}
+				txdata := &types.DynamicFeeTx{
+					ChainID:   t.chain.Config().ChainID,
+					Nonce:     nonce,
+					To:        &common.Address{0xaa},
+					Gas:       25000,
+					GasFeeCap: new(big.Int).Mul(t.chain.CurrentHeader().BaseFee, common.Big2),
+					GasTipCap: common.Big1,
+				}
+				s := types.MakeSigner(t.chain.Config(), t.chain.CurrentHeader().Number)
+				tx, _ := types.SignNewTx(pk, s, txdata)
+				if err := t.eth.SendTransaction(ctx, tx); err != nil {
+					return err
+				}
+				select {
+				case got := <-ch:
+					if got != tx.Hash() {
+						return fmt.Errorf("unexpected pending tx (got: %s, want: %s)", got, tx.Hash())
+					}
+				case err := <-sub.Err():
+					return err
+				case <-ctx.Done():
+					return fmt.Errorf("no pending transaction notification: %w", ctx.Err())
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// EthGetLogs stores a list of all tests against the method.
+var EthGetLogs = MethodTests{
+	Name: "eth_getLogs",
+	Tests: []Test{
+		{
+			Name:  "filter-block-range",
+			About: "gets all logs in blocks 7 through 8",
+			Run: func(ctx context.Context, t *T) error {
+				q := ethereum.FilterQuery{FromBlock: big.NewInt(7), ToBlock: big.NewInt(8)}
+				got, err := t.eth.FilterLogs(ctx, q)
+				if err != nil {
+					return err
+				}
+				return checkLogs(t, q, got)
+			},
+		},
+		{
+			Name:  "filter-address",
+			About: "gets all logs emitted by the log emitter contract",
+			Run: func(ctx context.Context, t *T) error {
+				emitter, err := logEmitter(t)
+				if err != nil {
+					return err
+				}
+				q := ethereum.FilterQuery{Addresses: []common.Address{emitter}}
+				got, err := t.eth.FilterLogs(ctx, q)
+				if err != nil {
+					return err
+				}
+				return checkLogs(t, q, got)
+			},
+		},
+		{
+			Name:  "filter-address-no-logs",
+			About: "gets logs of an account that never emitted one",
+			Run: func(ctx context.Context, t *T) error {
+				q := ethereum.FilterQuery{Addresses: []common.Address{{0xaa}}}
+				got, err := t.eth.FilterLogs(ctx, q)
+				if err != nil {
+					return err
+				}
+				return checkLogs(t, q, got)
+			},
+		},
+		{
+			Name:  "filter-topic-exact",
+			About: "gets logs with a certain first topic",
+			Run: func(ctx context.Context, t *T) error {
+				q := ethereum.FilterQuery{Topics: [][]common.Hash{{common.HexToHash("0xaa")}}}
+				got, err := t.eth.FilterLogs(ctx, q)
+				if err != nil {
+					return err
+				}
+				return checkLogs(t, q, got)
+			},
+		},
+		{
+			Name:  "filter-topic-wildcard",
+			About: "gets logs with any first topic and a certain second topic",
+			Run: func(ctx context.Context, t *T) error {
+				q := ethereum.FilterQuery{Topics: [][]common.Hash{nil, {common.HexToHash("0x02")}}}
+				got, err := t.eth.FilterLogs(ctx, q)
+				if err != nil {
+					return err
+				}
+				return checkLogs(t, q, got)
+			},
+		},
+		{
+			Name:  "filter-topic-or",
+			About: "gets logs with either of two first topics",
+			Run: func(ctx context.Context, t *T) error {
+				q := ethereum.FilterQuery{Topics: [][]common.Hash{{common.HexToHash("0xaa"), common.HexToHash("0xbb")}}}
+				got, err := t.eth.FilterLogs(ctx, q)
+				if err != nil {
+					return err
+				}
+				return checkLogs(t, q, got)
+			},
+		},
+		{
+			Name:  "filter-block-hash",
+			About: "gets all logs in block 7 by its hash",
+			Run: func(ctx context.Context, t *T) error {
+				hash := t.chain.GetHeaderByNumber(7).Hash()
+				q := ethereum.FilterQuery{BlockHash: &hash}
+				got, err := t.eth.FilterLogs(ctx, q)
+				if err != nil {
+					return err
+				}
+				return checkLogs(t, q, got)
+			},
+		},
+		{
+			Name:  "filter-unknown-block-hash",
+			About: "gets logs of a block hash that is not in the chain",
+			Run: func(ctx context.Context, t *T) error {
+				hash := common.HexToHash("0x01")
+				_, err := t.eth.FilterLogs(ctx, ethereum.FilterQuery{BlockHash: &hash})
+				return err
+			},
+			// Clients disagree on the code of this error.
+			ExpectError: &fixture.ExpectedError{},
+		},
+		{
+			Name:  "filter-invalid-range",
+			About: "gets logs with the start of the range after its end, which matches no blocks and thus no logs",
+			Run: func(ctx context.Context, t *T) error {
+				q := ethereum.FilterQuery{FromBlock: big.NewInt(8), ToBlock: big.NewInt(7)}
+				got, err := t.eth.FilterLogs(ctx, q)
+				if err != nil {
+					return err
+				}
+				if len(got) != 0 {
+					return fmt.Errorf("unexpected logs for invalid range (got: %d, want: 0)", len(got))
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// EthNewFilter stores a list of all tests against the method.
+var EthNewFilter = MethodTests{
+	Name: "eth_newFilter",
+	Tests: []Test{
+		{
+			Name:  "new-filter",
+			About: "creates a log filter for the log emitter contract",
+			// Filter ids are random.
+			Normalize: []fixture.Rule{SchemaOnly("result").For("eth_newFilter")},
+			Run: func(ctx context.Context, t *T) error {
+				emitter, err := logEmitter(t)
+				if err != nil {
+					return err
+				}
+				var id string
+				filter := map[string]interface{}{"fromBlock": "0x0", "toBlock": "latest", "address": emitter}
+				if err := t.rpc.CallContext(ctx, &id, "eth_newFilter", filter); err != nil {
+					return err
+				}
+				if id == "" {
+					return fmt.Errorf("empty filter id")
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// EthGetFilterLogs stores a list of all tests against the method.
+var EthGetFilterLogs = MethodTests{
+	Name: "eth_getFilterLogs",
+	Tests: []Test{
+		{
+			Name:  "get-filter-logs",
+			About: "creates a log filter and gets all logs matching it",
+			// Filter ids are random.
+			Normalize: []fixture.Rule{SchemaOnly("result").For("eth_newFilter")},
+			Run: func(ctx context.Context, t *T) error {
+				topic := common.HexToHash("0xaa")
+				var id string
+				filter := map[string]interface{}{"fromBlock": "0x0", "toBlock": "latest", "topics": [][]common.Hash{{topic}}}
+				if err := t.rpc.CallContext(ctx, &id, "eth_newFilter", filter); err != nil {
+					return err
+				}
+				var got []types.Log
+				if err := t.rpc.CallContext(ctx, &got, "eth_getFilterLogs", id); err != nil {
+					return err
+				}
+				return checkLogs(t, ethereum.FilterQuery{Topics: [][]common.Hash{{topic}}}, got)
+			},
+		},
+	},
+}
+
+// EthGetFilterChanges stores a list of all tests against the method.
+var EthGetFilterChanges = MethodTests{
+	Name: "eth_getFilterChanges",
+	Tests: []Test{
+		{
+			Name:  "get-filter-changes",
+			About: "creates a log filter and polls it for changes",
+			// Filter ids are random.
+			Normalize: []fixture.Rule{SchemaOnly("result").For("eth_newFilter")},
+			Run: func(ctx context.Context, t *T) error {
+				var id string
+				filter := map[string]interface{}{"fromBlock": "latest", "toBlock": "latest"}
+				if err := t.rpc.CallContext(ctx, &id, "eth_newFilter", filter); err != nil {
+					return err
+				}
+				var got []types.Log
+				if err := t.rpc.CallContext(ctx, &got, "eth_getFilterChanges", id); err != nil {
+					return err
+				}
+				// The chain doesn't advance, so there can't be any changes.
+				if len(got) != 0 {
+					return fmt.Errorf("unexpected filter changes (got: %d, want: 0)", len(got))
+				}
+				return nil
+			},
+		},
+	},
+}
+
 // EthGetUncleByBlockNumberAndIndex stores a list of all tests against the method.
 var EthGetUncleByBlockNumberAndIndex = MethodTests{
-	"eth_getUncleByBlockNumberAndIndex",
-	[]Test{
+	Name:  "eth_getUncleByBlockNumberAndIndex",
+	Chain: PreMergeChain,
+	Tests: []Test{
 		{
-			"get-uncle",
-			"gets uncle header",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-uncle",
+			About: "gets uncle header",
+			Run: func(ctx context.Context, t *T) error {
 				var got *types.Header
-				t.rpc.CallContext(ctx, got, "eth_getUncleByBlockNumberAndIndex", hexutil.Uint(2), hexutil.Uint(0))
-				want := t.chain.GetBlockByNumber(2).Uncles()[0]
-				if got.Hash() != want.Hash() {
-					return fmt.Errorf("mismatch uncle hash (got: %s, want: %s", got.Hash(), want.Hash())
+				if err := t.rpc.CallContext(ctx, &got, "eth_getUncleByBlockNumberAndIndex", hexutil.Uint(3), hexutil.Uint(0)); err != nil {
+					return err
+				}
+				return checkUncle(t, 3, 0, got)
+			},
+		},
+		{
+			Name:  "get-uncle-second",
+			About: "gets the second uncle header of a block with two uncles",
+			Run: func(ctx context.Context, t *T) error {
+				var got *types.Header
+				if err := t.rpc.CallContext(ctx, &got, "eth_getUncleByBlockNumberAndIndex", hexutil.Uint(4), hexutil.Uint(1)); err != nil {
+					return err
+				}
+				return checkUncle(t, 4, 1, got)
+			},
+		},
+		{
+			Name:  "get-uncle-out-of-range",
+			About: "gets an uncle beyond the uncles of the block",
+			Run: func(ctx context.Context, t *T) error {
+				var got *types.Header
+				if err := t.rpc.CallContext(ctx, &got, "eth_getUncleByBlockNumberAndIndex", hexutil.Uint(3), hexutil.Uint(1)); err != nil {
+					return err
+				}
+				if got != nil {
+					return fmt.Errorf("unexpected uncle %s", got.Hash())
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// EthGetUncleByBlockHashAndIndex stores a list of all tests against the method.
+var EthGetUncleByBlockHashAndIndex = MethodTests{
+	Name:  "eth_getUncleByBlockHashAndIndex",
+	Chain: PreMergeChain,
+	Tests: []Test{
+		{
+			Name:  "get-uncle",
+			About: "gets uncle header by the hash of the including block",
+			Run: func(ctx context.Context, t *T) error {
+				var got *types.Header
+				hash := t.chain.GetHeaderByNumber(4).Hash()
+				if err := t.rpc.CallContext(ctx, &got, "eth_getUncleByBlockHashAndIndex", hash, hexutil.Uint(0)); err != nil {
+					return err
+				}
+				return checkUncle(t, 4, 0, got)
+			},
+		},
+	},
+}
+
+// EthGetUncleCountByBlockNumber stores a list of all tests against the method.
+var EthGetUncleCountByBlockNumber = MethodTests{
+	Name:  "eth_getUncleCountByBlockNumber",
+	Chain: PreMergeChain,
+	Tests: []Test{
+		{
+			Name:  "get-uncle-count",
+			About: "gets the number of uncles of a block with two uncles",
+			Run: func(ctx context.Context, t *T) error {
+				var got hexutil.Uint
+				if err := t.rpc.CallContext(ctx, &got, "eth_getUncleCountByBlockNumber", hexutil.Uint(4)); err != nil {
+					return err
+				}
+				if want := len(t.chain.GetBlockByNumber(4).Uncles()); int(got) != want {
+					return fmt.Errorf("unexpected uncle count (got: %d, want: %d)", got, want)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "get-uncle-count-empty",
+			About: "gets the number of uncles of a block without uncles",
+			Run: func(ctx context.Context, t *T) error {
+				var got hexutil.Uint
+				if err := t.rpc.CallContext(ctx, &got, "eth_getUncleCountByBlockNumber", hexutil.Uint(1)); err != nil {
+					return err
+				}
+				if got != 0 {
+					return fmt.Errorf("unexpected uncle count (got: %d, want: 0)", got)
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// EthGetUncleCountByBlockHash stores a list of all tests against the method.
+var EthGetUncleCountByBlockHash = MethodTests{
+	Name:  "eth_getUncleCountByBlockHash",
+	Chain: PreMergeChain,
+	Tests: []Test{
+		{
+			Name:  "get-uncle-count",
+			About: "gets the number of uncles of a block by its hash",
+			Run: func(ctx context.Context, t *T) error {
+				var got hexutil.Uint
+				block := t.chain.GetBlockByNumber(3)
+				if err := t.rpc.CallContext(ctx, &got, "eth_getUncleCountByBlockHash", block.Hash()); err != nil {
+					return err
+				}
+				if want := len(block.Uncles()); int(got) != want {
+					return fmt.Errorf("unexpected uncle count (got: %d, want: %d)", got, want)
 				}
 				return nil
 			},
@@ -792,12 +1517,12 @@ var EthGetUncleByBlockNumberAndIndex = MethodTests{
 
 // EthGetProof stores a list of all tests against the method.
 var EthGetProof = MethodTests{
-	"eth_getProof",
-	[]Test{
+	Name: "eth_getProof",
+	Tests: []Test{
 		{
-			"get-account-proof",
-			"gets proof for a certain account",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-account-proof",
+			About: "gets proof for a certain account",
+			Run: func(ctx context.Context, t *T) error {
 				addr := common.Address{0xaa}
 				result, err := t.geth.GetProof(ctx, addr, []string{}, big.NewInt(3))
 				if err != nil {
@@ -812,9 +1537,9 @@ var EthGetProof = MethodTests{
 			},
 		},
 		{
-			"get-account-proof-blockhash",
-			"gets proof for a certain account at the specified blockhash",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-account-proof-blockhash",
+			About: "gets proof for a certain account at the specified blockhash",
+			Run: func(ctx context.Context, t *T) error {
 				addr := common.Address{0xaa}
 				type accountResult struct {
 					Balance *hexutil.Big `json:"balance"`
@@ -832,9 +1557,9 @@ var EthGetProof = MethodTests{
 			},
 		},
 		{
-			"get-account-proof-with-storage",
-			"gets proof for a certain account",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-account-proof-with-storage",
+			About: "gets proof for a certain account",
+			Run: func(ctx context.Context, t *T) error {
 				addr := common.Address{0xaa}
 				result, err := t.geth.GetProof(ctx, addr, []string{"0x01"}, big.NewInt(3))
 				if err != nil {
@@ -854,13 +1579,86 @@ var EthGetProof = MethodTests{
 	},
 }
 
+// AdminImportChain stores a list of all tests against the method.
+var AdminImportChain = MethodTests{
+	Name: "admin_importChain",
+	Tests: []Test{
+		{
+			Name:  "import-bad-block",
+			About: "imports a block with invalid gas used, which must be rejected without changing the head",
+			Run: func(ctx context.Context, t *T) error {
+				importErr := importBadBlock(ctx, t)
+				if err := checkHead(ctx, t); err != nil {
+					return err
+				}
+				return importErr
+			},
+			ExpectError: &fixture.ExpectedError{},
+			Mutates:     true,
+		},
+	},
+}
+
+// DebugGetBadBlocks stores a list of all tests against the method.
+var DebugGetBadBlocks = MethodTests{
+	Name: "debug_getBadBlocks",
+	Tests: []Test{
+		{
+			Name:  "get-bad-blocks-empty",
+			About: "gets the bad blocks of a client which hasn't seen any",
+			Run: func(ctx context.Context, t *T) error {
+				var got []json.RawMessage
+				if err := t.rpc.CallContext(ctx, &got, "debug_getBadBlocks"); err != nil {
+					return err
+				}
+				if len(got) != 0 {
+					return fmt.Errorf("unexpected bad blocks (got: %d, want: 0)", len(got))
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "get-bad-blocks",
+			About: "gets the bad blocks after a block with invalid gas used was rejected",
+			Run: func(ctx context.Context, t *T) error {
+				var rpcErr rpc.Error
+				if err := importBadBlock(ctx, t); !errors.As(err, &rpcErr) {
+					return err
+				}
+				var got []struct {
+					Hash common.Hash   `json:"hash"`
+					RLP  hexutil.Bytes `json:"rlp"`
+				}
+				if err := t.rpc.CallContext(ctx, &got, "debug_getBadBlocks"); err != nil {
+					return err
+				}
+				if len(got) != 1 {
+					return fmt.Errorf("unexpected bad blocks (got: %d, want: 1)", len(got))
+				}
+				if got[0].Hash != t.bad.Hash() {
+					return fmt.Errorf("unexpected bad block hash (got: %s, want: %s)", got[0].Hash, t.bad.Hash())
+				}
+				want, err := rlp.EncodeToBytes(t.bad)
+				if err != nil {
+					return err
+				}
+				if !bytes.Equal(got[0].RLP, want) {
+					return fmt.Errorf("unexpected bad block rlp (got: %s, want: %s)", got[0].RLP, hexutil.Bytes(want))
+				}
+				return checkHead(ctx, t)
+			},
+			Mutates: true,
+		},
+	},
+}
+
 var DebugGetRawHeader = MethodTests{
-	"debug_getRawHeader",
-	[]Test{
+	Name: "debug_getRawHeader",
+	Tests: []Test{
 		{
-			"get-genesis",
-			"gets block 0",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-genesis",
+			About: "gets block 0",
+			Run: func(ctx context.Context, t *T) error {
 				var got hexutil.Bytes
 				if err := t.rpc.CallContext(ctx, &got, "debug_getRawHeader", "0x0"); err != nil {
 					return err
@@ -869,9 +1667,9 @@ var DebugGetRawHeader = MethodTests{
 			},
 		},
 		{
-			"get-block-n",
-			"gets non-zero block",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-block-n",
+			About: "gets non-zero block",
+			Run: func(ctx context.Context, t *T) error {
 				var got hexutil.Bytes
 				if err := t.rpc.CallContext(ctx, &got, "debug_getRawHeader", "0x3"); err != nil {
 					return err
@@ -880,26 +1678,23 @@ var DebugGetRawHeader = MethodTests{
 			},
 		},
 		{
-			"get-invalid-number",
-			"gets block with invalid number formatting",
-			func(ctx context.Context, t *T) error {
-				err := t.rpc.CallContext(ctx, nil, "debug_getRawHeader", "2")
-				if !strings.HasPrefix(err.Error(), "invalid argument 0") {
-					return err
-				}
-				return nil
+			Name:  "get-invalid-number",
+			About: "gets block with invalid number formatting",
+			Run: func(ctx context.Context, t *T) error {
+				return t.rpc.CallContext(ctx, nil, "debug_getRawHeader", "2")
 			},
+			ExpectError: &fixture.ExpectedError{Code: CodeInvalidParams},
 		},
 	},
 }
 
 var DebugGetRawBlock = MethodTests{
-	"debug_getRawBlock",
-	[]Test{
+	Name: "debug_getRawBlock",
+	Tests: []Test{
 		{
-			"get-genesis",
-			"gets block 0",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-genesis",
+			About: "gets block 0",
+			Run: func(ctx context.Context, t *T) error {
 				var got hexutil.Bytes
 				if err := t.rpc.CallContext(ctx, &got, "debug_getRawBlock", "0x0"); err != nil {
 					return err
@@ -908,9 +1703,9 @@ var DebugGetRawBlock = MethodTests{
 			},
 		},
 		{
-			"get-block-n",
-			"gets non-zero block",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-block-n",
+			About: "gets non-zero block",
+			Run: func(ctx context.Context, t *T) error {
 				var got hexutil.Bytes
 				if err := t.rpc.CallContext(ctx, &got, "debug_getRawBlock", "0x3"); err != nil {
 					return err
@@ -919,57 +1714,51 @@ var DebugGetRawBlock = MethodTests{
 			},
 		},
 		{
-			"get-invalid-number",
-			"gets block with invalid number formatting",
-			func(ctx context.Context, t *T) error {
-				err := t.rpc.CallContext(ctx, nil, "debug_getRawBlock", "2")
-				if !strings.HasPrefix(err.Error(), "invalid argument 0") {
-					return err
-				}
-				return nil
+			Name:  "get-invalid-number",
+			About: "gets block with invalid number formatting",
+			Run: func(ctx context.Context, t *T) error {
+				return t.rpc.CallContext(ctx, nil, "debug_getRawBlock", "2")
 			},
+			ExpectError: &fixture.ExpectedError{Code: CodeInvalidParams},
 		},
 	},
 }
 
 var DebugGetRawReceipts = MethodTests{
-	"debug_getRawReceipts",
-	[]Test{
+	Name: "debug_getRawReceipts",
+	Tests: []Test{
 		{
-			"get-genesis",
-			"gets receipts for block 0",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-genesis",
+			About: "gets receipts for block 0",
+			Run: func(ctx context.Context, t *T) error {
 				return t.rpc.CallContext(ctx, nil, "debug_getRawReceipts", "0x0")
 			},
 		},
 		{
-			"get-block-n",
-			"gets receipts non-zero block",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-block-n",
+			About: "gets receipts non-zero block",
+			Run: func(ctx context.Context, t *T) error {
 				return t.rpc.CallContext(ctx, nil, "debug_getRawReceipts", "0x3")
 			},
 		},
 		{
-			"get-invalid-number",
-			"gets receipts with invalid number formatting",
-			func(ctx context.Context, t *T) error {
-				err := t.rpc.CallContext(ctx, nil, "debug_getRawReceipts", "2")
-				if !strings.HasPrefix(err.Error(), "invalid argument 0") {
-					return err
-				}
-				return nil
+			Name:  "get-invalid-number",
+			About: "gets receipts with invalid number formatting",
+			Run: func(ctx context.Context, t *T) error {
+				return t.rpc.CallContext(ctx, nil, "debug_getRawReceipts", "2")
 			},
+			ExpectError: &fixture.ExpectedError{Code: CodeInvalidParams},
 		},
 	},
 }
 
 var DebugGetRawTransaction = MethodTests{
-	"debug_getRawTransaction",
-	[]Test{
+	Name: "debug_getRawTransaction",
+	Tests: []Test{
 		{
-			"get-tx",
-			"gets tx rlp by hash",
-			func(ctx context.Context, t *T) error {
+			Name:  "get-tx",
+			About: "gets tx rlp by hash",
+			Run: func(ctx context.Context, t *T) error {
 				tx := t.chain.GetBlockByNumber(1).Transactions()[0]
 				var got hexutil.Bytes
 				if err := t.rpc.CallContext(ctx, &got, "debug_getRawTransaction", tx.Hash().Hex()); err != nil {
@@ -986,16 +1775,352 @@ var DebugGetRawTransaction = MethodTests{
 			},
 		},
 		{
-			"get-invalid-hash",
-			"gets tx with hash missing 0x prefix",
-			func(ctx context.Context, t *T) error {
-				var got hexutil.Bytes
-				err := t.rpc.CallContext(ctx, &got, "debug_getRawTransaction", "1000000000000000000000000000000000000000000000000000000000000001")
-				if !strings.HasPrefix(err.Error(), "invalid argument 0") {
+			Name:  "get-invalid-hash",
+			About: "gets tx with hash missing 0x prefix",
+			Run: func(ctx context.Context, t *T) error {
+				return t.rpc.CallContext(ctx, nil, "debug_getRawTransaction", "1000000000000000000000000000000000000000000000000000000000000001")
+			},
+			ExpectError: &fixture.ExpectedError{Code: CodeInvalidParams},
+		},
+	},
+}
+
+// Batch stores a list of tests of batch requests, which call several methods
+// at once.
+var Batch = MethodTests{
+	Name: "batch",
+	Tests: []Test{
+		{
+			Name:  "batch-calls",
+			About: "calls several methods in a single batch",
+			Run: func(ctx context.Context, t *T) error {
+				var (
+					number  hexutil.Uint64
+					chainID hexutil.Big
+					header  *types.Header
+					batch   = []rpc.BatchElem{
+						{Method: "eth_blockNumber", Result: &number},
+						{Method: "eth_chainId", Result: &chainID},
+						{Method: "eth_getBlockByNumber", Args: []interface{}{hexutil.Uint64(1), false}, Result: &header},
+					}
+				)
+				if err := t.rpc.BatchCallContext(ctx, batch); err != nil {
+					return err
+				}
+				if err := batchErrors(batch); err != nil {
 					return err
 				}
+				if want := t.chain.CurrentHeader().Number.Uint64(); uint64(number) != want {
+					return fmt.Errorf("unexpected block number (got: %d, want: %d)", number, want)
+				}
+				if want := t.chain.Config().ChainID; chainID.ToInt().Cmp(want) != 0 {
+					return fmt.Errorf("unexpected chain id (got: %s, want: %s)", chainID.ToInt(), want)
+				}
+				if want := t.chain.GetHeaderByNumber(1); header == nil || header.Hash() != want.Hash() {
+					return fmt.Errorf("unexpected block 1 (want: %s)", want.Hash())
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "batch-mixed-errors",
+			About: "calls several methods in a single batch, one of which fails",
+			// Clients word the error of the failing call differently.
+			Normalize: []fixture.Rule{Ignore("error.message").For("debug_getRawHeader")},
+			Run: func(ctx context.Context, t *T) error {
+				var (
+					number  hexutil.Uint64
+					raw     hexutil.Bytes
+					balance hexutil.Big
+					batch   = []rpc.BatchElem{
+						{Method: "eth_blockNumber", Result: &number},
+						{Method: "debug_getRawHeader", Args: []interface{}{"2"}, Result: &raw},
+						{Method: "eth_getBalance", Args: []interface{}{addr, "latest"}, Result: &balance},
+					}
+				)
+				if err := t.rpc.BatchCallContext(ctx, batch); err != nil {
+					return err
+				}
+				var rpcErr rpc.Error
+				if !errors.As(batch[1].Error, &rpcErr) {
+					return fmt.Errorf("expected json-rpc error for call 1, got: %v", batch[1].Error)
+				}
+				if rpcErr.ErrorCode() != CodeInvalidParams {
+					return fmt.Errorf("unexpected error code for call 1 (got: %d, want: %d)", rpcErr.ErrorCode(), CodeInvalidParams)
+				}
+				batch[1].Error = nil
+				if err := batchErrors(batch); err != nil {
+					return err
+				}
+				if want := t.chain.CurrentHeader().Number.Uint64(); uint64(number) != want {
+					return fmt.Errorf("unexpected block number (got: %d, want: %d)", number, want)
+				}
+				state, err := t.chain.State()
+				if err != nil {
+					return err
+				}
+				if want := state.GetBalance(addr); balance.ToInt().Cmp(want) != 0 {
+					return fmt.Errorf("unexpected balance (got: %s, want: %s)", balance.ToInt(), want)
+				}
 				return nil
 			},
 		},
+		{
+			Name:  "batch-empty",
+			About: "sends an empty batch, which is answered with a single invalid request error",
+			// Only the code of the error is specified.
+			Normalize: []fixture.Rule{Ignore("error.message")},
+			Run: func(ctx context.Context, t *T) error {
+				// The rpc client can't decode the response, so the
+				// batch is sent raw.
+				resp, err := t.send(ctx, json.RawMessage(`[]`))
+				if err != nil {
+					return err
+				}
+				var msg struct {
+					ID    json.RawMessage `json:"id"`
+					Error *struct {
+						Code int `json:"code"`
+					} `json:"error"`
+				}
+				if err := json.Unmarshal(resp, &msg); err != nil {
+					return fmt.Errorf("empty batch not answered with a single error: %s", resp)
+				}
+				if msg.Error == nil {
+					return fmt.Errorf("expected error, got: %s", resp)
+				}
+				if msg.Error.Code != CodeInvalidRequest {
+					return fmt.Errorf("unexpected error code (got: %d, want: %d)", msg.Error.Code, CodeInvalidRequest)
+				}
+				if string(msg.ID) != "null" {
+					return fmt.Errorf("unexpected id (got: %s, want: null)", msg.ID)
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// EngineExchangeCapabilities stores a list of all tests against the method.
+var EngineExchangeCapabilities = MethodTests{
+	Name: "engine_exchangeCapabilities",
+	Tests: []Test{
+		{
+			Name:  "exchange-capabilities",
+			About: "exchanges the supported engine API methods with the client",
+			Run: func(ctx context.Context, t *T) error {
+				var got []string
+				if err := t.engineCall(ctx, &got, "engine_exchangeCapabilities", engineMethods); err != nil {
+					return err
+				}
+				for _, want := range []string{"engine_newPayloadV2", "engine_forkchoiceUpdatedV2", "engine_getPayloadV2"} {
+					if !slices.Contains(got, want) {
+						return fmt.Errorf("capability %s missing (got: %v)", want, got)
+					}
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// EngineForkchoiceUpdatedV2 stores a list of all tests against the method.
+var EngineForkchoiceUpdatedV2 = MethodTests{
+	Name: "engine_forkchoiceUpdatedV2",
+	Tests: []Test{
+		{
+			Name:  "forkchoice-updated-head",
+			About: "updates the forkchoice to the current head",
+			Run: func(ctx context.Context, t *T) error {
+				var got engine.ForkChoiceResponse
+				head := t.chain.CurrentHeader().Hash()
+				state := engine.ForkchoiceStateV1{HeadBlockHash: head}
+				if err := t.engineCall(ctx, &got, "engine_forkchoiceUpdatedV2", state, nil); err != nil {
+					return err
+				}
+				if err := checkPayloadStatus(got.PayloadStatus, engine.VALID, &head); err != nil {
+					return err
+				}
+				if got.PayloadID != nil {
+					return fmt.Errorf("unexpected payload id %s", got.PayloadID)
+				}
+				return checkHead(ctx, t)
+			},
+			Mutates: true,
+		},
+		{
+			Name:  "forkchoice-updated-build",
+			About: "updates the forkchoice to the current head and starts building a payload on top of it",
+			// Payload ids are chosen by the client.
+			Normalize: []fixture.Rule{SchemaOnly("result.payloadId").For("engine_forkchoiceUpdatedV2")},
+			Run: func(ctx context.Context, t *T) error {
+				_, err := buildPayload(ctx, t)
+				return err
+			},
+			Mutates: true,
+		},
+		{
+			Name:  "forkchoice-updated-unknown-finalized",
+			About: "updates the forkchoice with a finalized block the client doesn't know",
+			Run: func(ctx context.Context, t *T) error {
+				state := engine.ForkchoiceStateV1{
+					HeadBlockHash:      t.chain.CurrentHeader().Hash(),
+					FinalizedBlockHash: common.Hash{0xff},
+				}
+				return t.engineCall(ctx, nil, "engine_forkchoiceUpdatedV2", state, nil)
+			},
+			ExpectError: &fixture.ExpectedError{Code: CodeInvalidForkchoiceState},
+			Mutates:     true,
+		},
+	},
+}
+
+// EngineGetPayloadV2 stores a list of all tests against the method.
+var EngineGetPayloadV2 = MethodTests{
+	Name: "engine_getPayloadV2",
+	Tests: []Test{
+		{
+			Name:  "get-payload",
+			About: "gets a payload built on top of the current head",
+			// Payload ids are chosen by the client.
+			Normalize: []fixture.Rule{SchemaOnly("result.payloadId").For("engine_forkchoiceUpdatedV2")},
+			Run: func(ctx context.Context, t *T) error {
+				_, err := buildPayload(ctx, t)
+				return err
+			},
+			Mutates: true,
+		},
+		{
+			Name:  "get-unknown-payload",
+			About: "gets a payload the client never started to build",
+			Run: func(ctx context.Context, t *T) error {
+				return t.engineCall(ctx, nil, "engine_getPayloadV2", engine.PayloadID{0x01})
+			},
+			ExpectError: &fixture.ExpectedError{Code: CodeUnknownPayload},
+		},
+	},
+}
+
+// EngineNewPayloadV2 stores a list of all tests against the method.
+var EngineNewPayloadV2 = MethodTests{
+	Name: "engine_newPayloadV2",
+	Tests: []Test{
+		{
+			Name:  "new-payload",
+			About: "builds a payload on top of the current head and imports it",
+			// Payload ids are chosen by the client.
+			Normalize: []fixture.Rule{SchemaOnly("result.payloadId").For("engine_forkchoiceUpdatedV2")},
+			Run: func(ctx context.Context, t *T) error {
+				payload, err := buildPayload(ctx, t)
+				if err != nil {
+					return err
+				}
+				var got engine.PayloadStatusV1
+				if err := t.engineCall(ctx, &got, "engine_newPayloadV2", payload); err != nil {
+					return err
+				}
+				return checkPayloadStatus(got, engine.VALID, &payload.BlockHash)
+			},
+			Mutates: true,
+		},
+		{
+			Name:  "new-payload-known",
+			About: "imports the payload of the current head, which the client already has",
+			Run: func(ctx context.Context, t *T) error {
+				head := t.chain.CurrentBlock()
+				payload := engine.BlockToExecutableData(t.chain.GetBlock(head.Hash(), head.Number.Uint64()), nil).ExecutionPayload
+				var got engine.PayloadStatusV1
+				if err := t.engineCall(ctx, &got, "engine_newPayloadV2", payload); err != nil {
+					return err
+				}
+				return checkPayloadStatus(got, engine.VALID, &payload.BlockHash)
+			},
+		},
+		{
+			Name:  "new-payload-bad-block",
+			About: "imports a payload with invalid gas used, which must be rejected without changing the head",
+			Run: func(ctx context.Context, t *T) error {
+				if t.bad == nil {
+					return fmt.Errorf("test chain has no bad block")
+				}
+				payload := engine.BlockToExecutableData(t.bad, nil).ExecutionPayload
+				var got engine.PayloadStatusV1
+				if err := t.engineCall(ctx, &got, "engine_newPayloadV2", payload); err != nil {
+					return err
+				}
+				parent := t.bad.ParentHash()
+				if err := checkPayloadStatus(got, engine.INVALID, &parent); err != nil {
+					return err
+				}
+				return checkHead(ctx, t)
+			},
+			Mutates: true,
+		},
+	},
+}
+
+// EngineGetPayloadBodiesByHashV1 stores a list of all tests against the method.
+var EngineGetPayloadBodiesByHashV1 = MethodTests{
+	Name: "engine_getPayloadBodiesByHashV1",
+	Tests: []Test{
+		{
+			Name:  "get-payload-bodies",
+			About: "gets the bodies of two blocks and of an unknown block",
+			Run: func(ctx context.Context, t *T) error {
+				hashes := []common.Hash{
+					t.chain.GetHeaderByNumber(2).Hash(),
+					t.chain.GetHeaderByNumber(4).Hash(),
+					{0xff},
+				}
+				var got []*engine.ExecutionPayloadBodyV1
+				if err := t.engineCall(ctx, &got, "engine_getPayloadBodiesByHashV1", hashes); err != nil {
+					return err
+				}
+				if len(got) != len(hashes) {
+					return fmt.Errorf("unexpected number of bodies (got: %d, want: %d)", len(got), len(hashes))
+				}
+				if got[2] != nil {
+					return fmt.Errorf("unexpected body of unknown block")
+				}
+				if err := checkPayloadBody(t, 2, got[0]); err != nil {
+					return err
+				}
+				return checkPayloadBody(t, 4, got[1])
+			},
+		},
+	},
+}
+
+// EngineGetPayloadBodiesByRangeV1 stores a list of all tests against the method.
+var EngineGetPayloadBodiesByRangeV1 = MethodTests{
+	Name: "engine_getPayloadBodiesByRangeV1",
+	Tests: []Test{
+		{
+			Name:  "get-payload-bodies",
+			About: "gets the bodies of blocks 1 to 3",
+			Run: func(ctx context.Context, t *T) error {
+				var got []*engine.ExecutionPayloadBodyV1
+				if err := t.engineCall(ctx, &got, "engine_getPayloadBodiesByRangeV1", hexutil.Uint64(1), hexutil.Uint64(3)); err != nil {
+					return err
+				}
+				if len(got) != 3 {
+					return fmt.Errorf("unexpected number of bodies (got: %d, want: 3)", len(got))
+				}
+				for i, body := range got {
+					if err := checkPayloadBody(t, uint64(i+1), body); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "get-payload-bodies-zero-count",
+			About: "gets an empty range of bodies",
+			Run: func(ctx context.Context, t *T) error {
+				return t.engineCall(ctx, nil, "engine_getPayloadBodiesByRangeV1", hexutil.Uint64(1), hexutil.Uint64(0))
+			},
+			ExpectError: &fixture.ExpectedError{Code: CodeInvalidParams},
+		},
 	},
 }
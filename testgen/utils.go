@@ -1,10 +1,20 @@
 package testgen
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 func checkHeaderRLP(t *T, n uint64, got []byte) error {
@@ -36,3 +46,380 @@ func checkBlockRLP(t *T, n uint64, got []byte) error {
 	}
 	return nil
 }
+
+// chainTx returns the i-th transaction of block n in the test chain, checking
+// that it is of the expected type.
+func chainTx(t *T, n uint64, i int, typ uint8) (*types.Block, *types.Transaction, error) {
+	block := t.chain.GetBlockByNumber(n)
+	if block == nil {
+		return nil, nil, fmt.Errorf("unable to load block %d from test chain", n)
+	}
+	if len(block.Transactions()) <= i {
+		return nil, nil, fmt.Errorf("block %d has no tx %d", n, i)
+	}
+	tx := block.Transactions()[i]
+	if tx.Type() != typ {
+		return nil, nil, fmt.Errorf("unexpected type of tx %d in block %d (got: %d, want: %d)", i, n, tx.Type(), typ)
+	}
+	return block, tx, nil
+}
+
+// checkTransaction retrieves the i-th transaction of block n by its hash and
+// checks it matches the test chain.
+func checkTransaction(ctx context.Context, t *T, n uint64, i int, typ uint8) error {
+	_, want, err := chainTx(t, n, i, typ)
+	if err != nil {
+		return err
+	}
+	got, _, err := t.eth.TransactionByHash(ctx, want.Hash())
+	if err != nil {
+		return err
+	}
+	if got.Hash() != want.Hash() {
+		return fmt.Errorf("tx mismatch (got: %s, want: %s)", got.Hash(), want.Hash())
+	}
+	return nil
+}
+
+// checkReceipt retrieves the receipt of the i-th transaction of block n and
+// checks it matches the test chain.
+func checkReceipt(ctx context.Context, t *T, n uint64, i int, typ uint8) (*types.Receipt, error) {
+	block, tx, err := chainTx(t, n, i, typ)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := t.eth.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return nil, err
+	}
+	want := t.chain.GetReceiptsByHash(block.Hash())[i]
+	gotBin, _ := receipt.MarshalBinary()
+	wantBin, _ := want.MarshalBinary()
+	if !bytes.Equal(gotBin, wantBin) {
+		return nil, fmt.Errorf("receipt mismatch (got: %s, want: %s)", hexutil.Bytes(gotBin), hexutil.Bytes(wantBin))
+	}
+	if receipt.ContractAddress != want.ContractAddress {
+		return nil, fmt.Errorf("contract address mismatch (got: %s, want: %s)", receipt.ContractAddress, want.ContractAddress)
+	}
+	return receipt, nil
+}
+
+// logEmitter returns the address of the log emitting contract deployed in
+// block 6 of the test chain.
+func logEmitter(t *T) (common.Address, error) {
+	block := t.chain.GetBlockByNumber(6)
+	if block == nil {
+		return common.Address{}, fmt.Errorf("unable to load block 6 from test chain")
+	}
+	receipts := t.chain.GetReceiptsByHash(block.Hash())
+	if len(receipts) == 0 || receipts[0].ContractAddress == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("block 6 does not deploy the log emitter")
+	}
+	return receipts[0].ContractAddress, nil
+}
+
+// filterLogs returns the logs of the test chain matching the query.
+func filterLogs(t *T, q ethereum.FilterQuery) []*types.Log {
+	var blocks []*types.Block
+	if q.BlockHash != nil {
+		if block := t.chain.GetBlockByHash(*q.BlockHash); block != nil {
+			blocks = append(blocks, block)
+		}
+	} else {
+		var (
+			from = uint64(0)
+			head = t.chain.CurrentHeader().Number.Uint64()
+			to   = head
+		)
+		if q.FromBlock != nil {
+			from = q.FromBlock.Uint64()
+		}
+		// Blocks past the head don't exist and have no logs.
+		if q.ToBlock != nil && q.ToBlock.Uint64() < head {
+			to = q.ToBlock.Uint64()
+		}
+		for n := from; n <= to; n++ {
+			blocks = append(blocks, t.chain.GetBlockByNumber(n))
+		}
+	}
+	var out []*types.Log
+	for _, block := range blocks {
+		for _, receipt := range t.chain.GetReceiptsByHash(block.Hash()) {
+			for _, log := range receipt.Logs {
+				if matchLog(log, q) {
+					out = append(out, log)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// matchLog reports whether the log matches the query's addresses and topics.
+func matchLog(log *types.Log, q ethereum.FilterQuery) bool {
+	if len(q.Addresses) != 0 {
+		found := false
+		for _, addr := range q.Addresses {
+			if addr == log.Address {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(q.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, sub := range q.Topics {
+		// An empty position is a wildcard, otherwise any topic may match.
+		if len(sub) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range sub {
+			if topic == log.Topics[i] {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// checkLogs checks the logs returned by the client match the logs of the test
+// chain for the query.
+func checkLogs(t *T, q ethereum.FilterQuery, got []types.Log) error {
+	want := filterLogs(t, q)
+	if len(got) != len(want) {
+		return fmt.Errorf("unexpected number of logs (got: %d, want: %d)", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].TxHash != want[i].TxHash || got[i].Index != want[i].Index || got[i].BlockHash != want[i].BlockHash {
+			return fmt.Errorf("log %d mismatch (got: %s/%d, want: %s/%d)", i, got[i].TxHash, got[i].Index, want[i].TxHash, want[i].Index)
+		}
+	}
+	return nil
+}
+
+// powBlock holds the proof-of-work fields of a JSON-RPC block.
+type powBlock struct {
+	Hash            common.Hash      `json:"hash"`
+	Difficulty      *hexutil.Big     `json:"difficulty"`
+	TotalDifficulty *hexutil.Big     `json:"totalDifficulty"`
+	MixHash         common.Hash      `json:"mixHash"`
+	Nonce           types.BlockNonce `json:"nonce"`
+	Uncles          []common.Hash    `json:"uncles"`
+}
+
+// checkPowBlock compares the proof-of-work fields of a block to block n of
+// the test chain.
+func checkPowBlock(t *T, n uint64, got *powBlock) error {
+	if got == nil {
+		return fmt.Errorf("block %d not found", n)
+	}
+	want := t.chain.GetBlockByNumber(n)
+	if want == nil {
+		return fmt.Errorf("unable to load block %d from test chain", n)
+	}
+	if got.Hash != want.Hash() {
+		return fmt.Errorf("unexpected block hash (got: %s, want: %s)", got.Hash, want.Hash())
+	}
+	if got.Difficulty == nil || got.Difficulty.ToInt().Cmp(want.Difficulty()) != 0 {
+		return fmt.Errorf("unexpected difficulty (got: %v, want: %v)", got.Difficulty, want.Difficulty())
+	}
+	td := t.chain.GetTd(want.Hash(), n)
+	if got.TotalDifficulty == nil || got.TotalDifficulty.ToInt().Cmp(td) != 0 {
+		return fmt.Errorf("unexpected total difficulty (got: %v, want: %v)", got.TotalDifficulty, td)
+	}
+	if got.MixHash != want.MixDigest() || got.Nonce != want.Header().Nonce {
+		return fmt.Errorf("unexpected seal (got: %s/%x, want: %s/%x)", got.MixHash, got.Nonce, want.MixDigest(), want.Header().Nonce)
+	}
+	uncles := want.Uncles()
+	if len(got.Uncles) != len(uncles) {
+		return fmt.Errorf("unexpected number of uncles (got: %d, want: %d)", len(got.Uncles), len(uncles))
+	}
+	for i, uncle := range uncles {
+		if got.Uncles[i] != uncle.Hash() {
+			return fmt.Errorf("unexpected uncle %d (got: %s, want: %s)", i, got.Uncles[i], uncle.Hash())
+		}
+	}
+	return nil
+}
+
+// checkUncle compares an uncle returned by the client to uncle i of block n
+// of the test chain.
+func checkUncle(t *T, n uint64, i int, got *types.Header) error {
+	if got == nil {
+		return fmt.Errorf("uncle %d of block %d not found", i, n)
+	}
+	block := t.chain.GetBlockByNumber(n)
+	if block == nil {
+		return fmt.Errorf("unable to load block %d from test chain", n)
+	}
+	if i >= len(block.Uncles()) {
+		return fmt.Errorf("block %d has no uncle %d", n, i)
+	}
+	if want := block.Uncles()[i]; got.Hash() != want.Hash() {
+		return fmt.Errorf("unexpected uncle hash (got: %s, want: %s)", got.Hash(), want.Hash())
+	}
+	return nil
+}
+
+// importBadBlock asks the client to import the invalid child of the head from
+// the absolute path of its file. It returns the client's json-rpc error if the
+// client rejected the block. Otherwise, i.e. if the block was imported or the
+// client didn't get to validate it, it returns an error which isn't a json-rpc
+// error, so that the test fails.
+func importBadBlock(ctx context.Context, t *T) error {
+	if t.bad == nil {
+		return fmt.Errorf("test chain has no bad block")
+	}
+	if t.badFile == "" {
+		return fmt.Errorf("%w: client does not share the filesystem", ErrSkip)
+	}
+	err := t.rpc.CallContext(ctx, nil, "admin_importChain", t.badFile)
+	if err == nil {
+		return fmt.Errorf("bad block %s imported", t.bad.Hash())
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return err
+	}
+	switch {
+	case rpcErr.ErrorCode() == -32601:
+		return fmt.Errorf("client does not serve admin_importChain: %v", err)
+	case strings.Contains(strings.ToLower(err.Error()), "no such file"):
+		return fmt.Errorf("client failed to read %s: %v", t.badFile, err)
+	}
+	return err
+}
+
+// checkHead checks that the client's canonical head is the head of the test
+// chain.
+func checkHead(ctx context.Context, t *T) error {
+	got, err := t.eth.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if want := t.chain.CurrentHeader(); got.Hash() != want.Hash() {
+		return fmt.Errorf("unexpected head (got: %d %s, want: %d %s)", got.Number, got.Hash(), want.Number, want.Hash())
+	}
+	return nil
+}
+
+// engineMethods are the engine API methods rpctestgen has tests for.
+var engineMethods = []string{
+	"engine_newPayloadV1",
+	"engine_newPayloadV2",
+	"engine_forkchoiceUpdatedV1",
+	"engine_forkchoiceUpdatedV2",
+	"engine_getPayloadV1",
+	"engine_getPayloadV2",
+	"engine_getPayloadBodiesByHashV1",
+	"engine_getPayloadBodiesByRangeV1",
+}
+
+// buildPayload asks the client to build a payload on top of the head of the
+// test chain and retrieves it. The payload attributes are fixed, so that the
+// payload is the same in every run.
+func buildPayload(ctx context.Context, t *T) (*engine.ExecutableData, error) {
+	var (
+		head  = t.chain.CurrentHeader()
+		state = engine.ForkchoiceStateV1{HeadBlockHash: head.Hash()}
+		attrs = &engine.PayloadAttributes{
+			Timestamp:             head.Time + 12,
+			Random:                common.Hash{0x01},
+			SuggestedFeeRecipient: common.Address{0xfe},
+			Withdrawals:           []*types.Withdrawal{},
+		}
+		fcu engine.ForkChoiceResponse
+	)
+	if err := t.engineCall(ctx, &fcu, "engine_forkchoiceUpdatedV2", state, attrs); err != nil {
+		return nil, err
+	}
+	if err := checkPayloadStatus(fcu.PayloadStatus, engine.VALID, &state.HeadBlockHash); err != nil {
+		return nil, err
+	}
+	if fcu.PayloadID == nil {
+		return nil, fmt.Errorf("no payload id")
+	}
+	var got engine.ExecutionPayloadEnvelope
+	if err := t.engineCall(ctx, &got, "engine_getPayloadV2", fcu.PayloadID); err != nil {
+		return nil, err
+	}
+	payload := got.ExecutionPayload
+	if payload == nil {
+		return nil, fmt.Errorf("no execution payload")
+	}
+	if payload.ParentHash != head.Hash() {
+		return nil, fmt.Errorf("unexpected parent hash (got: %s, want: %s)", payload.ParentHash, head.Hash())
+	}
+	if want := head.Number.Uint64() + 1; payload.Number != want {
+		return nil, fmt.Errorf("unexpected block number (got: %d, want: %d)", payload.Number, want)
+	}
+	if payload.Timestamp != attrs.Timestamp || payload.Random != attrs.Random || payload.FeeRecipient != attrs.SuggestedFeeRecipient {
+		return nil, fmt.Errorf("payload doesn't match the attributes")
+	}
+	return payload, nil
+}
+
+// checkPayloadStatus checks the status and latest valid hash of a payload
+// status.
+func checkPayloadStatus(got engine.PayloadStatusV1, status string, latestValid *common.Hash) error {
+	if got.Status != status {
+		msg := ""
+		if got.ValidationError != nil {
+			msg = ": " + *got.ValidationError
+		}
+		return fmt.Errorf("unexpected payload status (got: %s, want: %s)%s", got.Status, status, msg)
+	}
+	if got.LatestValidHash == nil || *got.LatestValidHash != *latestValid {
+		return fmt.Errorf("unexpected latest valid hash (got: %v, want: %s)", got.LatestValidHash, latestValid)
+	}
+	return nil
+}
+
+// checkPayloadBody compares a payload body to the body of block n of the test
+// chain.
+func checkPayloadBody(t *T, n uint64, got *engine.ExecutionPayloadBodyV1) error {
+	if got == nil {
+		return fmt.Errorf("body of block %d not found", n)
+	}
+	block := t.chain.GetBlockByNumber(n)
+	if block == nil {
+		return fmt.Errorf("unable to load block %d from test chain", n)
+	}
+	if len(got.TransactionData) != len(block.Transactions()) {
+		return fmt.Errorf("unexpected number of transactions in block %d (got: %d, want: %d)", n, len(got.TransactionData), len(block.Transactions()))
+	}
+	for i, tx := range block.Transactions() {
+		want, err := tx.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got.TransactionData[i], want) {
+			return fmt.Errorf("unexpected transaction %d in block %d", i, n)
+		}
+	}
+	var (
+		gotRoot  = types.DeriveSha(types.Withdrawals(got.Withdrawals), trie.NewStackTrie(nil))
+		wantRoot = types.DeriveSha(block.Withdrawals(), trie.NewStackTrie(nil))
+	)
+	if gotRoot != wantRoot {
+		return fmt.Errorf("unexpected withdrawals in block %d", n)
+	}
+	return nil
+}
+
+// batchErrors returns the first error of the calls in the batch.
+func batchErrors(batch []rpc.BatchElem) error {
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return fmt.Errorf("call %d (%s) failed: %w", i, elem.Method, elem.Error)
+		}
+	}
+	return nil
+}